@@ -0,0 +1,45 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/MuriloChianfa/liblpm/go/internal/lpmtest"
+)
+
+// benchmarkFuzzLookup populates an IPv4 table by replaying the inserts of
+// a count-step trace from lpmtest's generator (so the prefix mix matches
+// what TestFuzz exercises) and reports lookups/sec against it. Only about
+// half of a trace's steps are inserts, and some of those repeat the same
+// edge-case prefixes, so the table holds fewer than count prefixes.
+func benchmarkFuzzLookup(b *testing.B, count int) {
+	h, err := lpmtest.NewHarness(true)
+	if err != nil {
+		b.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Close()
+
+	for _, op := range lpmtest.GenerateOps(42, true, count) {
+		if op.Kind == lpmtest.OpInsert {
+			h.Table.Insert(op.Prefix, op.NextHop)
+		}
+	}
+	addrs := lpmtest.GenerateAddrs(43, true, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Table.Lookup(addrs[i%len(addrs)])
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "lookups/sec")
+}
+
+func BenchmarkFuzzLookup_10000(b *testing.B) {
+	benchmarkFuzzLookup(b, 10000)
+}
+
+func BenchmarkFuzzLookup_100000(b *testing.B) {
+	benchmarkFuzzLookup(b, 100000)
+}
+
+func BenchmarkFuzzLookup_1000000(b *testing.B) {
+	benchmarkFuzzLookup(b, 1000000)
+}
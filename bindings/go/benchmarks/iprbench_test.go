@@ -317,6 +317,49 @@ func BenchmarkLookupBatchIPv4(b *testing.B) {
 	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(addrs)), "ns/lookup")
 }
 
+// BenchmarkLookupPrefixIPv4 benchmarks LookupPrefix at RIB scale, where
+// longestMatch's O(n) route-shadow scan (see its doc comment in query.go)
+// is expected to dominate over Lookup's cgo-backed next-hop half.
+func BenchmarkLookupPrefixIPv4(b *testing.B) {
+	prefixes := generateRandomIPv4Prefixes(100000)
+	addrs := generateRandomIPv4Addrs(1000)
+
+	table, _ := liblpm.NewTableIPv4()
+	defer table.Close()
+
+	for i, prefix := range prefixes {
+		table.Insert(prefix, liblpm.NextHop(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.LookupPrefix(addrs[i%len(addrs)])
+	}
+}
+
+// BenchmarkLookupPrefixBatchIPv4 benchmarks LookupPrefixBatch at RIB
+// scale, where only the next-hop half of the work is a single cgo
+// crossing - the prefix half still re-scans the route shadow once per
+// address (see LookupPrefixBatch's doc comment in query.go).
+func BenchmarkLookupPrefixBatchIPv4(b *testing.B) {
+	prefixes := generateRandomIPv4Prefixes(100000)
+	addrs := generateRandomIPv4Addrs(1000)
+
+	table, _ := liblpm.NewTableIPv4()
+	defer table.Close()
+
+	for i, prefix := range prefixes {
+		table.Insert(prefix, liblpm.NextHop(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.LookupPrefixBatch(addrs)
+	}
+
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(addrs)), "ns/lookup")
+}
+
 // IPv6 Benchmarks
 
 // BenchmarkInsertRandomPfxsIPv6_1_000 benchmarks inserting 1,000 random IPv6 prefixes.
@@ -0,0 +1,46 @@
+package fibio
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/MuriloChianfa/liblpm/go/liblpm"
+)
+
+func TestLoadText(t *testing.T) {
+	table, err := liblpm.NewBatchTableIPv4WithBackend(liblpm.BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	input := "# comment\n10.0.0.0/8\t100\n\n10.1.0.0/16\t200\n"
+	n, err := LoadText(strings.NewReader(input), table)
+	if err != nil {
+		t.Fatalf("LoadText failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("LoadText inserted %d prefixes, want 2", n)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{netip.MustParseAddr("10.1.0.1")})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != 200 {
+		t.Errorf("Lookup(10.1.0.1) = %d, want 200", results[0])
+	}
+}
+
+func TestLoadTextRejectsMalformedLine(t *testing.T) {
+	table, err := liblpm.NewBatchTableIPv4WithBackend(liblpm.BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	if _, err := LoadText(strings.NewReader("not-a-valid-line\n"), table); err == nil {
+		t.Error("Expected LoadText to reject a malformed line")
+	}
+}
@@ -0,0 +1,301 @@
+// Package mrt implements a minimal decoder for RFC 6396 MRT TABLE_DUMP_V2
+// RIB dumps, just enough to drive fibio.LoadMRT. It is not a general MRT
+// library: BGP4MP message records, OSPF/IS-IS records, and TABLE_DUMP
+// (v1) records are all skipped.
+package mrt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+)
+
+// MRT type/subtype constants used by TABLE_DUMP_V2 RIB dumps (RFC 6396
+// section 4.3). Exported so a caller writing its own TABLE_DUMP_V2 stream
+// (liblpm's DumpMRT, say) can target the same wire values this package's
+// Decode/DecodeFull expect, instead of re-declaring them.
+const (
+	TypeTableDumpV2 = 13
+
+	SubtypePeerIndexTable = 1
+	SubtypeRIBIPv4Unicast = 2
+	SubtypeRIBIPv6Unicast = 4
+
+	PeerTypeIPv6Flag = 0x01
+	PeerTypeAS4Flag  = 0x02
+)
+
+// BGP path attribute type codes (RFC 4271 section 5, RFC 4760 section 3)
+// that RIBEntry.NextHop looks for.
+const (
+	attrFlagExtendedLength = 0x10
+
+	attrTypeNextHop     = 3
+	attrTypeMPReachNLRI = 14
+)
+
+// RIBEntry is one peer's route for a prefix within a TABLE_DUMP_V2 RIB
+// entry (RFC 6396 section 4.3.4). Attributes holds the raw BGP path
+// attributes exactly as they appear in the dump, unparsed; NextHop
+// extracts the advertised next hop from them on demand, since that's the
+// only attribute most FIB importers need.
+type RIBEntry struct {
+	PeerIndex      uint16
+	OriginatedTime uint32
+	Attributes     []byte
+}
+
+// NextHop scans Attributes for a NEXT_HOP (IPv4 routes) or MP_REACH_NLRI
+// (IPv6 routes) path attribute and returns the next hop it advertises.
+// The second return value is false if neither attribute is present.
+func (e RIBEntry) NextHop() (netip.Addr, bool) {
+	b := e.Attributes
+	for len(b) >= 2 {
+		flags, typ := b[0], b[1]
+		b = b[2:]
+
+		var length int
+		if flags&attrFlagExtendedLength != 0 {
+			if len(b) < 2 {
+				return netip.Addr{}, false
+			}
+			length = int(binary.BigEndian.Uint16(b))
+			b = b[2:]
+		} else {
+			if len(b) < 1 {
+				return netip.Addr{}, false
+			}
+			length = int(b[0])
+			b = b[1:]
+		}
+		if len(b) < length {
+			return netip.Addr{}, false
+		}
+		value := b[:length]
+		b = b[length:]
+
+		switch typ {
+		case attrTypeNextHop:
+			if len(value) == 4 {
+				return netip.AddrFrom4([4]byte(value)), true
+			}
+		case attrTypeMPReachNLRI:
+			if addr, ok := mpReachNextHop(value); ok {
+				return addr, true
+			}
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// mpReachNextHop extracts the next hop from an MP_REACH_NLRI attribute
+// (RFC 4760 section 3): AFI(2) SAFI(1) NextHopLen(1) NextHop(var) ...
+func mpReachNextHop(value []byte) (netip.Addr, bool) {
+	if len(value) < 4 {
+		return netip.Addr{}, false
+	}
+	nhLen := int(value[3])
+	value = value[4:]
+	if len(value) < nhLen {
+		return netip.Addr{}, false
+	}
+
+	switch {
+	case nhLen == 4:
+		return netip.AddrFrom4([4]byte(value[:4])), true
+	case nhLen >= 16:
+		// A global next hop optionally followed by a link-local one;
+		// the global address is always first.
+		return netip.AddrFrom16([16]byte(value[:16])), true
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+// Peer is one entry of a PEER_INDEX_TABLE record (RFC 6396 section 4.3.1):
+// one of the collector's BGP peers, in the order RIBEntry.PeerIndex refers
+// to them by.
+type Peer struct {
+	Address netip.Addr
+	ASN     uint32
+}
+
+// Decode reads MRT common-header-delimited records from r until EOF,
+// invoking onRoute once per RIB entry set: every peer's route for one
+// prefix, exactly as they are grouped in the dump. Only TABLE_DUMP_V2
+// RIB_IPV4_UNICAST and RIB_IPV6_UNICAST records are understood; every
+// other record (PEER_INDEX_TABLE included) is skipped using the common
+// header's Length field. It is DecodeFull with onPeers omitted.
+func Decode(r io.Reader, onRoute func(prefix netip.Prefix, entries []RIBEntry) error) error {
+	return DecodeFull(r, nil, onRoute)
+}
+
+// DecodeFull is Decode plus onPeers, invoked once if and when a
+// PEER_INDEX_TABLE record is seen, before any RIB record that refers to
+// it by PeerIndex. onPeers may be nil, in which case PEER_INDEX_TABLE
+// records are skipped exactly as Decode skips them.
+func DecodeFull(r io.Reader, onPeers func(peers []Peer) error, onRoute func(prefix netip.Prefix, entries []RIBEntry) error) error {
+	var hdr [12]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("mrt: reading common header: %w", err)
+		}
+
+		typ := binary.BigEndian.Uint16(hdr[4:6])
+		subtype := binary.BigEndian.Uint16(hdr[6:8])
+		length := binary.BigEndian.Uint32(hdr[8:12])
+
+		msg := make([]byte, length)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return fmt.Errorf("mrt: reading message body: %w", err)
+		}
+
+		if typ != TypeTableDumpV2 {
+			continue
+		}
+
+		switch subtype {
+		case SubtypePeerIndexTable:
+			if onPeers == nil {
+				continue
+			}
+			peers, err := decodePeerIndexTable(msg)
+			if err != nil {
+				return err
+			}
+			if err := onPeers(peers); err != nil {
+				return err
+			}
+		case SubtypeRIBIPv4Unicast:
+			if err := decodeRIB(msg, 4, onRoute); err != nil {
+				return err
+			}
+		case SubtypeRIBIPv6Unicast:
+			if err := decodeRIB(msg, 16, onRoute); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decodePeerIndexTable parses a PEER_INDEX_TABLE message (RFC 6396
+// section 4.3.1): a collector BGP ID (4 bytes), a view name (2-byte
+// length + bytes), a peer count (2 bytes), then that many peer entries
+// (type flags byte, BGP ID, peer IP, peer AS).
+func decodePeerIndexTable(msg []byte) ([]Peer, error) {
+	if len(msg) < 4+2 {
+		return nil, fmt.Errorf("mrt: PEER_INDEX_TABLE message too short")
+	}
+	msg = msg[4:] // collector BGP ID, unused here
+
+	viewLen := int(binary.BigEndian.Uint16(msg[:2]))
+	msg = msg[2:]
+	if len(msg) < viewLen+2 {
+		return nil, fmt.Errorf("mrt: PEER_INDEX_TABLE message truncated before peer count")
+	}
+	msg = msg[viewLen:] // view name, unused here
+
+	peerCount := int(binary.BigEndian.Uint16(msg[:2]))
+	msg = msg[2:]
+
+	peers := make([]Peer, 0, peerCount)
+	for i := 0; i < peerCount; i++ {
+		if len(msg) < 1+4 {
+			return nil, fmt.Errorf("mrt: PEER_INDEX_TABLE entry truncated")
+		}
+		peerType := msg[0]
+		msg = msg[1+4:] // type flags, then BGP ID, unused here
+
+		var addr netip.Addr
+		if peerType&PeerTypeIPv6Flag != 0 {
+			if len(msg) < 16 {
+				return nil, fmt.Errorf("mrt: PEER_INDEX_TABLE entry truncated before peer IP")
+			}
+			addr = netip.AddrFrom16([16]byte(msg[:16]))
+			msg = msg[16:]
+		} else {
+			if len(msg) < 4 {
+				return nil, fmt.Errorf("mrt: PEER_INDEX_TABLE entry truncated before peer IP")
+			}
+			addr = netip.AddrFrom4([4]byte(msg[:4]))
+			msg = msg[4:]
+		}
+
+		asLen := 2
+		if peerType&PeerTypeAS4Flag != 0 {
+			asLen = 4
+		}
+		if len(msg) < asLen {
+			return nil, fmt.Errorf("mrt: PEER_INDEX_TABLE entry truncated before peer AS")
+		}
+		var asn uint32
+		if asLen == 4 {
+			asn = binary.BigEndian.Uint32(msg[:4])
+		} else {
+			asn = uint32(binary.BigEndian.Uint16(msg[:2]))
+		}
+		msg = msg[asLen:]
+
+		peers = append(peers, Peer{Address: addr, ASN: asn})
+	}
+
+	return peers, nil
+}
+
+// decodeRIB parses the body of a single RIB_IPV4_UNICAST or
+// RIB_IPV6_UNICAST message (RFC 6396 section 4.3.2).
+func decodeRIB(msg []byte, addrSize int, onRoute func(netip.Prefix, []RIBEntry) error) error {
+	// Sequence Number (4 bytes) is unused here.
+	if len(msg) < 5 {
+		return fmt.Errorf("mrt: RIB message too short")
+	}
+	prefixLen := int(msg[4])
+	msg = msg[5:]
+
+	prefixBytes := (prefixLen + 7) / 8
+	if prefixBytes > addrSize || len(msg) < prefixBytes+2 {
+		return fmt.Errorf("mrt: RIB message truncated before prefix")
+	}
+
+	var addr [16]byte
+	copy(addr[:], msg[:prefixBytes])
+	msg = msg[prefixBytes:]
+
+	var ip netip.Addr
+	if addrSize == 4 {
+		ip = netip.AddrFrom4([4]byte(addr[:4]))
+	} else {
+		ip = netip.AddrFrom16(addr)
+	}
+	prefix := netip.PrefixFrom(ip, prefixLen)
+
+	entryCount := int(binary.BigEndian.Uint16(msg[:2]))
+	msg = msg[2:]
+
+	entries := make([]RIBEntry, 0, entryCount)
+	for i := 0; i < entryCount; i++ {
+		if len(msg) < 8 {
+			return fmt.Errorf("mrt: RIB entry truncated")
+		}
+		peerIndex := binary.BigEndian.Uint16(msg[:2])
+		originatedTime := binary.BigEndian.Uint32(msg[2:6])
+		attrLen := int(binary.BigEndian.Uint16(msg[6:8]))
+		msg = msg[8:]
+
+		if len(msg) < attrLen {
+			return fmt.Errorf("mrt: RIB entry attributes truncated")
+		}
+		entries = append(entries, RIBEntry{
+			PeerIndex:      peerIndex,
+			OriginatedTime: originatedTime,
+			Attributes:     msg[:attrLen],
+		})
+		msg = msg[attrLen:]
+	}
+
+	return onRoute(prefix, entries)
+}
@@ -0,0 +1,92 @@
+package mrt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+)
+
+// buildRIBIPv4Record assembles a single MRT TABLE_DUMP_V2 RIB_IPV4_UNICAST
+// record (common header + body) carrying one prefix and one RIB entry
+// whose attributes are exactly attrs.
+func buildRIBIPv4Record(t *testing.T, prefix netip.Prefix, attrs []byte) []byte {
+	t.Helper()
+
+	addr4 := prefix.Addr().As4()
+	prefixBytes := (prefix.Bits() + 7) / 8
+
+	var body bytes.Buffer
+	binary.Write(&body, binary.BigEndian, uint32(0)) // sequence number
+	body.WriteByte(byte(prefix.Bits()))
+	body.Write(addr4[:prefixBytes])
+	binary.Write(&body, binary.BigEndian, uint16(1)) // entry count
+
+	binary.Write(&body, binary.BigEndian, uint16(0)) // peer index
+	binary.Write(&body, binary.BigEndian, uint32(0)) // originated time
+	binary.Write(&body, binary.BigEndian, uint16(len(attrs)))
+	body.Write(attrs)
+
+	var record bytes.Buffer
+	binary.Write(&record, binary.BigEndian, uint32(0)) // timestamp
+	binary.Write(&record, binary.BigEndian, uint16(TypeTableDumpV2))
+	binary.Write(&record, binary.BigEndian, uint16(SubtypeRIBIPv4Unicast))
+	binary.Write(&record, binary.BigEndian, uint32(body.Len()))
+	record.Write(body.Bytes())
+
+	return record.Bytes()
+}
+
+func TestDecodeRIBIPv4Unicast(t *testing.T) {
+	nextHop := []byte{0x40, attrTypeNextHop, 4, 10, 0, 0, 1}
+	prefix := netip.MustParsePrefix("192.168.1.0/24")
+
+	data := buildRIBIPv4Record(t, prefix, nextHop)
+
+	var gotPrefix netip.Prefix
+	var gotEntries []RIBEntry
+	err := Decode(bytes.NewReader(data), func(p netip.Prefix, entries []RIBEntry) error {
+		gotPrefix = p
+		gotEntries = entries
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if gotPrefix != prefix {
+		t.Errorf("Decode prefix = %v, want %v", gotPrefix, prefix)
+	}
+	if len(gotEntries) != 1 {
+		t.Fatalf("Decode entries = %d, want 1", len(gotEntries))
+	}
+
+	addr, ok := gotEntries[0].NextHop()
+	if !ok {
+		t.Fatal("RIBEntry.NextHop() found no next hop")
+	}
+	if addr != netip.MustParseAddr("10.0.0.1") {
+		t.Errorf("RIBEntry.NextHop() = %v, want 10.0.0.1", addr)
+	}
+}
+
+func TestDecodeSkipsUnknownRecords(t *testing.T) {
+	var unknown bytes.Buffer
+	binary.Write(&unknown, binary.BigEndian, uint32(0))
+	binary.Write(&unknown, binary.BigEndian, uint16(99)) // not TABLE_DUMP_V2
+	binary.Write(&unknown, binary.BigEndian, uint16(1))
+	binary.Write(&unknown, binary.BigEndian, uint32(3))
+	unknown.Write([]byte{1, 2, 3})
+
+	calls := 0
+	err := Decode(bytes.NewReader(unknown.Bytes()), func(netip.Prefix, []RIBEntry) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Decode invoked onRoute %d times for a non-TABLE_DUMP_V2 record, want 0", calls)
+	}
+}
@@ -0,0 +1,102 @@
+package lpmtest
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/MuriloChianfa/liblpm/go/liblpm"
+)
+
+// Harness replays a trace of Ops against both a real liblpm.Table and a
+// Reference, so TestFuzz-style callers can assert they never disagree.
+type Harness struct {
+	IsIPv4 bool
+	Table  *liblpm.Table
+	Ref    *Reference
+}
+
+// NewHarness creates an empty table of the requested address family
+// alongside a matching Reference.
+func NewHarness(isIPv4 bool) (*Harness, error) {
+	var table *liblpm.Table
+	var err error
+	if isIPv4 {
+		table, err = liblpm.NewTableIPv4()
+	} else {
+		table, err = liblpm.NewTableIPv6()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Harness{IsIPv4: isIPv4, Table: table, Ref: NewReference(isIPv4)}, nil
+}
+
+// Close releases the harness's table.
+func (h *Harness) Close() error {
+	return h.Table.Close()
+}
+
+// Mismatch describes the first Op at which Run found the table and the
+// reference disagreeing.
+type Mismatch struct {
+	Step int
+	Op   Op
+	Addr netip.Addr // set for OpLookup/OpLookupBatch mismatches
+
+	GotNextHop, WantNextHop liblpm.NextHop
+	GotOK, WantOK           bool
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("step %d (%s %v): got (%d, %v), want (%d, %v)",
+		m.Step, m.Op.Kind, m.Addr, uint32(m.GotNextHop), m.GotOK, uint32(m.WantNextHop), m.WantOK)
+}
+
+// Run replays ops against h.Table and h.Ref in lockstep, returning the
+// first Mismatch it finds, or nil if every step agreed. Insert/Delete
+// errors from the real table are not themselves failures - GenerateOps
+// never produces a prefix/address-family mismatch, but a caller replaying
+// a hand-edited corpus file might, and the reference simply mirrors
+// whatever the real table actually did.
+func (h *Harness) Run(ops []Op) *Mismatch {
+	for i, op := range ops {
+		switch op.Kind {
+		case OpInsert:
+			if err := h.Table.Insert(op.Prefix, op.NextHop); err == nil {
+				h.Ref.Insert(op.Prefix, op.NextHop)
+			}
+		case OpDelete:
+			if err := h.Table.Delete(op.Prefix); err == nil {
+				h.Ref.Delete(op.Prefix)
+			}
+		case OpLookup:
+			addr := op.Addrs[0]
+			gotNextHop, gotOK := h.Table.Lookup(addr)
+			wantNextHop, wantOK := h.Ref.Lookup(addr)
+			if gotOK != wantOK || (gotOK && gotNextHop != wantNextHop) {
+				return &Mismatch{
+					Step: i, Op: op, Addr: addr,
+					GotNextHop: gotNextHop, GotOK: gotOK,
+					WantNextHop: wantNextHop, WantOK: wantOK,
+				}
+			}
+		case OpLookupBatch:
+			got, err := h.Table.LookupBatch(op.Addrs)
+			if err != nil {
+				return &Mismatch{Step: i, Op: op}
+			}
+			want := h.Ref.LookupBatch(op.Addrs)
+			for j, addr := range op.Addrs {
+				gotOK, wantOK := got[j].IsValid(), want[j].IsValid()
+				if gotOK != wantOK || (gotOK && got[j] != want[j]) {
+					return &Mismatch{
+						Step: i, Op: op, Addr: addr,
+						GotNextHop: got[j], GotOK: gotOK,
+						WantNextHop: want[j], WantOK: wantOK,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
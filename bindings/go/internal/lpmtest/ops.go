@@ -0,0 +1,250 @@
+package lpmtest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/MuriloChianfa/liblpm/go/liblpm"
+)
+
+// OpKind identifies which Table/Reference method an Op drives.
+type OpKind int
+
+const (
+	OpInsert OpKind = iota
+	OpDelete
+	OpLookup
+	OpLookupBatch
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpInsert:
+		return "insert"
+	case OpDelete:
+		return "delete"
+	case OpLookup:
+		return "lookup"
+	case OpLookupBatch:
+		return "lookupbatch"
+	default:
+		return fmt.Sprintf("OpKind(%d)", int(k))
+	}
+}
+
+// Op is a single step in a generated or replayed trace. Prefix is set for
+// OpInsert/OpDelete, Addrs for OpLookup (one element) and OpLookupBatch
+// (any number).
+type Op struct {
+	Kind    OpKind
+	Prefix  netip.Prefix
+	NextHop liblpm.NextHop
+	Addrs   []netip.Addr
+}
+
+// GenerateOps produces a deterministic sequence of n operations for the
+// given address family from seed - the same (seed, isIPv4, n) always
+// yields the same trace. About two in five inserts reuse one of a
+// handful of edge-case prefixes (the default route, a host route, and,
+// for IPv6, an IPv4-mapped range) so duplicate inserts, delete-then-
+// reinsert, and overlapping coverage all get exercised rather than left
+// to chance.
+func GenerateOps(seed int64, isIPv4 bool, n int) []Op {
+	rng := rand.New(rand.NewSource(seed))
+	edgeCases := edgeCasePrefixes(isIPv4)
+
+	ops := make([]Op, 0, n)
+	var installed []netip.Prefix // prefixes this trace has inserted at least once, for Delete/reinsert bias
+
+	for i := 0; i < n; i++ {
+		switch {
+		case rng.Intn(3) == 0 && len(installed) > 0 && rng.Intn(2) == 0:
+			// Delete a previously installed prefix, including ones
+			// already deleted, to exercise double-delete and
+			// delete-then-reinsert.
+			prefix := installed[rng.Intn(len(installed))]
+			ops = append(ops, Op{Kind: OpDelete, Prefix: prefix})
+		case rng.Intn(4) == 0:
+			// Bias toward a shared pool of edge-case prefixes so they
+			// get inserted, overlapped and overwritten repeatedly.
+			prefix := edgeCases[rng.Intn(len(edgeCases))]
+			nextHop := liblpm.NextHop(rng.Uint32() &^ 0x80000000)
+			ops = append(ops, Op{Kind: OpInsert, Prefix: prefix, NextHop: nextHop})
+			installed = append(installed, prefix)
+		case rng.Intn(2) == 0:
+			prefix := randomPrefix(rng, isIPv4)
+			nextHop := liblpm.NextHop(rng.Uint32() &^ 0x80000000)
+			ops = append(ops, Op{Kind: OpInsert, Prefix: prefix, NextHop: nextHop})
+			installed = append(installed, prefix)
+		case rng.Intn(2) == 0:
+			ops = append(ops, Op{Kind: OpLookup, Addrs: []netip.Addr{randomAddr(rng, isIPv4)}})
+		default:
+			count := 1 + rng.Intn(8)
+			addrs := make([]netip.Addr, count)
+			for j := range addrs {
+				addrs[j] = randomAddr(rng, isIPv4)
+			}
+			ops = append(ops, Op{Kind: OpLookupBatch, Addrs: addrs})
+		}
+	}
+	return ops
+}
+
+// GenerateAddrs produces n deterministic addresses for the given address
+// family from seed, for callers that just want lookup targets rather than
+// a full Op trace (benchmarks, mainly).
+func GenerateAddrs(seed int64, isIPv4 bool, n int) []netip.Addr {
+	rng := rand.New(rand.NewSource(seed))
+	addrs := make([]netip.Addr, n)
+	for i := range addrs {
+		addrs[i] = randomAddr(rng, isIPv4)
+	}
+	return addrs
+}
+
+// edgeCasePrefixes returns the prefixes GenerateOps biases toward: the
+// default route, a single host route, a documentation range (a /24 for
+// IPv4, a /32 for IPv6), and, for IPv6, an IPv4-mapped range and host
+// address - the cases most likely to expose off-by-one bugs in
+// prefix-length handling.
+func edgeCasePrefixes(isIPv4 bool) []netip.Prefix {
+	if isIPv4 {
+		return []netip.Prefix{
+			netip.MustParsePrefix("0.0.0.0/0"),
+			netip.MustParsePrefix("192.0.2.1/32"),
+			netip.MustParsePrefix("198.51.100.0/24"),
+		}
+	}
+	return []netip.Prefix{
+		netip.MustParsePrefix("::/0"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("::ffff:192.0.2.0/120"),
+		netip.MustParsePrefix("::ffff:198.51.100.1/128"),
+	}
+}
+
+func randomPrefix(rng *rand.Rand, isIPv4 bool) netip.Prefix {
+	if isIPv4 {
+		bits := rng.Intn(33)
+		addr := netip.AddrFrom4([4]byte{byte(rng.Uint32()), byte(rng.Uint32()), byte(rng.Uint32()), byte(rng.Uint32())})
+		p, err := addr.Prefix(bits)
+		if err != nil {
+			return netip.MustParsePrefix("10.0.0.0/8")
+		}
+		return p
+	}
+
+	bits := rng.Intn(129)
+	var raw [16]byte
+	for i := range raw {
+		raw[i] = byte(rng.Uint32())
+	}
+	addr := netip.AddrFrom16(raw)
+	p, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.MustParsePrefix("2001:db8::/32")
+	}
+	return p
+}
+
+func randomAddr(rng *rand.Rand, isIPv4 bool) netip.Addr {
+	if isIPv4 {
+		return netip.AddrFrom4([4]byte{byte(rng.Uint32()), byte(rng.Uint32()), byte(rng.Uint32()), byte(rng.Uint32())})
+	}
+	var raw [16]byte
+	for i := range raw {
+		raw[i] = byte(rng.Uint32())
+	}
+	return netip.AddrFrom16(raw)
+}
+
+// WriteTrace writes ops as plain text, one op per line, so a failing
+// TestFuzz run can save the exact sequence that broke it and ReadTrace
+// can replay it later via -corpus.
+func WriteTrace(w io.Writer, ops []Op) error {
+	bw := bufio.NewWriter(w)
+	for _, op := range ops {
+		var line string
+		switch op.Kind {
+		case OpInsert:
+			line = fmt.Sprintf("insert %s %d", op.Prefix, uint32(op.NextHop))
+		case OpDelete:
+			line = fmt.Sprintf("delete %s", op.Prefix)
+		case OpLookup, OpLookupBatch:
+			addrs := make([]string, len(op.Addrs))
+			for i, a := range op.Addrs {
+				addrs[i] = a.String()
+			}
+			line = fmt.Sprintf("%s %s", op.Kind, strings.Join(addrs, ","))
+		}
+		if _, err := fmt.Fprintln(bw, line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadTrace parses a trace written by WriteTrace.
+func ReadTrace(r io.Reader) ([]Op, error) {
+	var ops []Op
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("lpmtest: line %d: malformed trace line %q", lineNum, line)
+		}
+
+		switch fields[0] {
+		case "insert":
+			parts := strings.SplitN(fields[1], " ", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("lpmtest: line %d: malformed insert %q", lineNum, line)
+			}
+			prefix, err := netip.ParsePrefix(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("lpmtest: line %d: %w", lineNum, err)
+			}
+			nextHop, err := strconv.ParseUint(parts[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("lpmtest: line %d: %w", lineNum, err)
+			}
+			ops = append(ops, Op{Kind: OpInsert, Prefix: prefix, NextHop: liblpm.NextHop(nextHop)})
+		case "delete":
+			prefix, err := netip.ParsePrefix(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("lpmtest: line %d: %w", lineNum, err)
+			}
+			ops = append(ops, Op{Kind: OpDelete, Prefix: prefix})
+		case "lookup", "lookupbatch":
+			var addrs []netip.Addr
+			for _, s := range strings.Split(fields[1], ",") {
+				addr, err := netip.ParseAddr(s)
+				if err != nil {
+					return nil, fmt.Errorf("lpmtest: line %d: %w", lineNum, err)
+				}
+				addrs = append(addrs, addr)
+			}
+			kind := OpLookup
+			if fields[0] == "lookupbatch" {
+				kind = OpLookupBatch
+			}
+			ops = append(ops, Op{Kind: kind, Addrs: addrs})
+		default:
+			return nil, fmt.Errorf("lpmtest: line %d: unknown op %q", lineNum, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
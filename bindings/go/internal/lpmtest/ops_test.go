@@ -0,0 +1,59 @@
+package lpmtest
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateOpsIsDeterministic(t *testing.T) {
+	a := GenerateOps(7, true, 200)
+	b := GenerateOps(7, true, 200)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Expected GenerateOps to produce identical traces for the same seed")
+	}
+
+	c := GenerateOps(8, true, 200)
+	if reflect.DeepEqual(a, c) {
+		t.Error("Expected GenerateOps to produce a different trace for a different seed")
+	}
+}
+
+func TestGenerateOpsCoversIPv6EdgeCases(t *testing.T) {
+	ops := GenerateOps(1, false, 500)
+	var sawDefaultRoute, sawHostRoute, sawMappedIPv4 bool
+	for _, op := range ops {
+		if op.Kind != OpInsert {
+			continue
+		}
+		switch {
+		case op.Prefix.Bits() == 0:
+			sawDefaultRoute = true
+		case op.Prefix.Bits() == 128:
+			sawHostRoute = true
+		case op.Prefix.Addr().Is4In6():
+			sawMappedIPv4 = true
+		}
+	}
+	if !sawDefaultRoute || !sawHostRoute || !sawMappedIPv4 {
+		t.Errorf("Expected IPv6 trace to cover /0, /128 and IPv4-mapped prefixes, got default=%v host=%v mapped=%v",
+			sawDefaultRoute, sawHostRoute, sawMappedIPv4)
+	}
+}
+
+func TestWriteTraceReadTraceRoundTrip(t *testing.T) {
+	ops := GenerateOps(3, true, 100)
+
+	var buf bytes.Buffer
+	if err := WriteTrace(&buf, ops); err != nil {
+		t.Fatalf("WriteTrace failed: %v", err)
+	}
+
+	got, err := ReadTrace(&buf)
+	if err != nil {
+		t.Fatalf("ReadTrace failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, ops) {
+		t.Errorf("ReadTrace(WriteTrace(ops)) != ops")
+	}
+}
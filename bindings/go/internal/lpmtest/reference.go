@@ -0,0 +1,78 @@
+// Package lpmtest provides a deliberately simple, correctness-first LPM
+// implementation and a deterministic operation generator, so the cgo-backed
+// liblpm.Table can be property-tested against something trivially
+// obviously-correct rather than only against itself.
+package lpmtest
+
+import (
+	"net/netip"
+
+	"github.com/MuriloChianfa/liblpm/go/liblpm"
+)
+
+// Reference is a pure-Go LPM: every route lives in a map and Lookup
+// linearly scans it for the longest match. It's O(n) per lookup rather
+// than the trie's O(1)-ish, which is the point - it has no clever parts
+// left to get wrong.
+type Reference struct {
+	routes map[netip.Prefix]liblpm.NextHop
+}
+
+// NewReference creates an empty reference table. isIPv4 has no bearing on
+// Reference's own behavior (it isn't family-specific) but is accepted to
+// keep its constructor symmetric with liblpm.NewTableIPv4/NewTableIPv6 and
+// NewHarness, which do need it.
+func NewReference(isIPv4 bool) *Reference {
+	return &Reference{
+		routes: make(map[netip.Prefix]liblpm.NextHop),
+	}
+}
+
+// Insert installs prefix with the given next hop, overwriting any route
+// already installed at that exact prefix.
+func (r *Reference) Insert(prefix netip.Prefix, nextHop liblpm.NextHop) {
+	r.routes[prefix.Masked()] = nextHop
+}
+
+// Delete removes prefix, reporting whether it was present.
+func (r *Reference) Delete(prefix netip.Prefix) bool {
+	prefix = prefix.Masked()
+	if _, ok := r.routes[prefix]; !ok {
+		return false
+	}
+	delete(r.routes, prefix)
+	return true
+}
+
+// Lookup returns the next hop of the longest installed prefix containing
+// addr, and whether any route matched at all.
+func (r *Reference) Lookup(addr netip.Addr) (liblpm.NextHop, bool) {
+	bestBits := -1
+	var bestNextHop liblpm.NextHop
+	for p, nextHop := range r.routes {
+		if p.Bits() > bestBits && p.Contains(addr) {
+			bestBits = p.Bits()
+			bestNextHop = nextHop
+		}
+	}
+	return bestNextHop, bestBits >= 0
+}
+
+// LookupBatch looks up each address in turn, in the same order, reporting
+// liblpm.InvalidNextHop for any address with no match.
+func (r *Reference) LookupBatch(addrs []netip.Addr) []liblpm.NextHop {
+	results := make([]liblpm.NextHop, len(addrs))
+	for i, addr := range addrs {
+		nextHop, ok := r.Lookup(addr)
+		if !ok {
+			nextHop = liblpm.InvalidNextHop
+		}
+		results[i] = nextHop
+	}
+	return results
+}
+
+// Len reports how many routes are currently installed.
+func (r *Reference) Len() int {
+	return len(r.routes)
+}
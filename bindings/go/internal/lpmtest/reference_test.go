@@ -0,0 +1,55 @@
+package lpmtest
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/MuriloChianfa/liblpm/go/liblpm"
+)
+
+func TestReferenceLookupPicksLongestMatch(t *testing.T) {
+	r := NewReference(true)
+	r.Insert(netip.MustParsePrefix("10.0.0.0/8"), 1)
+	r.Insert(netip.MustParsePrefix("10.0.0.0/16"), 2)
+	r.Insert(netip.MustParsePrefix("10.0.0.0/24"), 3)
+
+	nextHop, ok := r.Lookup(netip.MustParseAddr("10.0.0.1"))
+	if !ok || nextHop != 3 {
+		t.Errorf("Lookup = %v/%v, want 3/true", nextHop, ok)
+	}
+
+	if _, ok := r.Lookup(netip.MustParseAddr("192.0.2.1")); ok {
+		t.Error("Expected Lookup to report false for an unmatched address")
+	}
+}
+
+func TestReferenceDeleteReportsPresence(t *testing.T) {
+	r := NewReference(true)
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+
+	if r.Delete(prefix) {
+		t.Error("Expected Delete on an empty reference to report false")
+	}
+
+	r.Insert(prefix, 1)
+	if !r.Delete(prefix) {
+		t.Error("Expected Delete to report true for an installed prefix")
+	}
+	if _, ok := r.Lookup(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Error("Expected the route to be gone after Delete")
+	}
+}
+
+func TestReferenceLookupBatch(t *testing.T) {
+	r := NewReference(true)
+	r.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1)
+
+	got := r.LookupBatch([]netip.Addr{
+		netip.MustParseAddr("10.0.0.1"),
+		netip.MustParseAddr("192.0.2.1"),
+	})
+	want := []liblpm.NextHop{1, liblpm.InvalidNextHop}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("LookupBatch = %v, want %v", got, want)
+	}
+}
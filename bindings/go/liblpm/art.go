@@ -0,0 +1,475 @@
+package liblpm
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"sort"
+	"unsafe"
+)
+
+// artStrideBits is the number of prefix bits each level of the ART
+// consumes. An IPv4 address decomposes into 4 levels, IPv6 into 16.
+const artStrideBits = 8
+
+// artStrideSize is the number of distinct byte values at a stride
+// (2^artStrideBits).
+const artStrideSize = 1 << artStrideBits
+
+// artRoute is an explicitly inserted (as opposed to painted) route,
+// recorded so Delete can restore the correct parent value into the
+// descendant slots it painted.
+type artRoute struct {
+	nextHop NextHop
+	length  uint8
+}
+
+// artNode is one level of the multi-level Allotment Routing Table
+// described in Hariguchi's ART paper and used by Tailscale's net/art.
+// Each node owns one 8-bit stride: leaf slots hold the route "painted"
+// over that stride (the most specific prefix terminating within this
+// node), and children continue the trie for prefixes that need more
+// specific bits beyond this stride. Longest-prefix match is then a
+// single array index per stride instead of a bit-by-bit walk.
+//
+// gen tags which backend generation last wrote this node. insert/delete
+// compare it against the backend's current generation to decide whether
+// the node is still exclusively owned (safe to mutate in place) or
+// potentially shared with an outstanding Snapshot (must be path-copied
+// first). This is the standard persistent-tree technique: Snapshot only
+// needs to bump a counter, and later mutations clone exactly the nodes
+// on the path they touch, leaving the rest of the tree - and therefore
+// the snapshot's view of it - untouched.
+type artNode struct {
+	gen      uint64
+	leafHop  [artStrideSize]NextHop
+	leafSet  [artStrideSize]bool
+	leafLen  [artStrideSize]uint8
+	children [artStrideSize]*artNode
+
+	// explicit records routes literally inserted at this level, keyed by
+	// a heap index (1<<length + value>>(8-length)) that uniquely
+	// identifies a (value-range, length) pair. It lets Delete repaint
+	// only the descendants it owns, restoring whichever covering route
+	// (if any) was previously shadowed.
+	//
+	// explicitGen tracks which generation privately owns this map,
+	// independent of gen: clone() leaves explicit aliased to the
+	// original node's map (a pass-through clone that's only updating a
+	// child pointer never touches explicit), and ownExplicit copies it
+	// lazily the first time this node's own routes actually change.
+	explicit    map[int]artRoute
+	explicitGen uint64
+}
+
+func newARTNode(gen uint64) *artNode {
+	n := &artNode{gen: gen, explicitGen: gen}
+	for i := range n.leafHop {
+		n.leafHop[i] = InvalidNextHop
+	}
+	return n
+}
+
+// clone makes a path-copy of n tagged with gen. Fixed-size arrays copy by
+// value; children and explicit are carried over as-is and only
+// deep-copied lazily - children when a specific child is itself mutated
+// (via the recursive call in insert/delete), explicit via ownExplicit
+// when this node's own routes change - so a clone that's only on the
+// path to a deeper change stays as cheap as copying the struct.
+func (n *artNode) clone(gen uint64) *artNode {
+	c := *n
+	c.gen = gen
+	return &c
+}
+
+// ownExplicit ensures explicit is privately owned by gen, deep-copying it
+// from whatever generation last owned it if necessary.
+func (n *artNode) ownExplicit(gen uint64) {
+	if n.explicitGen == gen {
+		if n.explicit == nil {
+			n.explicit = make(map[int]artRoute)
+		}
+		return
+	}
+	m := make(map[int]artRoute, len(n.explicit))
+	for k, v := range n.explicit {
+		m[k] = v
+	}
+	n.explicit = m
+	n.explicitGen = gen
+}
+
+// artRange returns the [base, base+size) span of byte values painted by
+// a route of the given length anchored at value v within one stride.
+func artRange(v byte, length int) (base, size int) {
+	size = 1 << (artStrideBits - length)
+	base = (int(v) >> (artStrideBits - length)) << (artStrideBits - length)
+	return base, size
+}
+
+// artHeapIndex returns the heap-style index identifying the exact
+// (value-range, length) pair a route occupies within one stride.
+func artHeapIndex(v byte, length int) int {
+	return (1 << length) + (int(v) >> (artStrideBits - length))
+}
+
+// insert paints the stride range covered by (bytes[offset], length) with
+// nextHop, recursing into (creating if needed) a child node when the
+// prefix extends past this stride's 8 bits. It returns the node to use
+// in the caller's child slot - itself, unless it had to be path-copied
+// because it was still tagged with an older generation.
+func (n *artNode) insert(bytes []byte, offset, length int, nextHop NextHop, gen uint64) *artNode {
+	if n.gen != gen {
+		n = n.clone(gen)
+	}
+
+	if length <= artStrideBits {
+		n.ownExplicit(gen)
+		n.explicit[artHeapIndex(bytes[offset], length)] = artRoute{nextHop: nextHop, length: uint8(length)}
+
+		base, size := artRange(bytes[offset], length)
+		for i := base; i < base+size; i++ {
+			if !n.leafSet[i] || n.leafLen[i] <= uint8(length) {
+				n.leafHop[i] = nextHop
+				n.leafLen[i] = uint8(length)
+				n.leafSet[i] = true
+			}
+		}
+		return n
+	}
+
+	v := bytes[offset]
+	child := n.children[v]
+	if child == nil {
+		child = newARTNode(gen)
+	}
+	n.children[v] = child.insert(bytes, offset+1, length-artStrideBits, nextHop, gen)
+	return n
+}
+
+// delete removes the explicit route at (bytes[offset], length), repainting
+// the descendant slots it owned with whichever less-specific route (if
+// any) previously covered them. It returns the node to use in the
+// caller's child slot (see insert), the next hop the route held, and
+// whether the route existed.
+func (n *artNode) delete(bytes []byte, offset, length int, gen uint64) (*artNode, NextHop, bool) {
+	if length <= artStrideBits {
+		idx := artHeapIndex(bytes[offset], length)
+		route, ok := n.explicit[idx]
+		if !ok {
+			return n, InvalidNextHop, false
+		}
+
+		if n.gen != gen {
+			n = n.clone(gen)
+		}
+		n.ownExplicit(gen)
+		delete(n.explicit, idx)
+
+		// Walk ancestors in the heap numbering to find the nearest
+		// still-explicit route covering the same range, if any.
+		restoreHop := InvalidNextHop
+		restoreLen := -1
+		for anc := idx >> 1; anc >= 1; anc >>= 1 {
+			if r, ok := n.explicit[anc]; ok {
+				restoreHop = r.nextHop
+				restoreLen = int(r.length)
+				break
+			}
+		}
+
+		base, size := artRange(bytes[offset], length)
+		for i := base; i < base+size; i++ {
+			if n.leafLen[i] != uint8(length) || n.leafHop[i] != route.nextHop {
+				// Shadowed by a more specific route inserted later; leave it.
+				continue
+			}
+			if restoreLen >= 0 {
+				n.leafHop[i] = restoreHop
+				n.leafLen[i] = uint8(restoreLen)
+			} else {
+				n.leafHop[i] = InvalidNextHop
+				n.leafLen[i] = 0
+				n.leafSet[i] = false
+			}
+		}
+
+		return n, route.nextHop, true
+	}
+
+	v := bytes[offset]
+	child := n.children[v]
+	if child == nil {
+		return n, InvalidNextHop, false
+	}
+	newChild, prevNextHop, existed := child.delete(bytes, offset+1, length-artStrideBits, gen)
+	if !existed {
+		return n, InvalidNextHop, false
+	}
+	if n.gen != gen {
+		n = n.clone(gen)
+	}
+	n.children[v] = newChild
+	return n, prevNextHop, true
+}
+
+// lookup walks down into children first so that a more specific route in
+// a deeper stride wins, falling back to this node's painted leaf value
+// when no descendant matches.
+func (n *artNode) lookup(bytes []byte, offset int) (NextHop, bool) {
+	v := bytes[offset]
+	if child := n.children[v]; child != nil && offset+1 < len(bytes) {
+		if nh, ok := child.lookup(bytes, offset+1); ok {
+			return nh, true
+		}
+	}
+	if n.leafSet[v] {
+		return n.leafHop[v], true
+	}
+	return InvalidNextHop, false
+}
+
+// walk calls fn for every explicit route under n in ascending heap-index
+// order, then recurses into children. bytes accumulates the address
+// bytes down to this node's stride (byteOffset); it's owned by the
+// caller and reused across the whole traversal, so walk only ever
+// writes bytes[byteOffset] before using it - never anything deeper.
+// Returns false to propagate an early stop requested by fn.
+func (n *artNode) walk(bytes []byte, byteOffset int, isIPv4 bool, fn func(netip.Prefix, NextHop) bool) bool {
+	if len(n.explicit) > 0 {
+		idxs := make([]int, 0, len(n.explicit))
+		for idx := range n.explicit {
+			idxs = append(idxs, idx)
+		}
+		sort.Ints(idxs)
+
+		for _, idx := range idxs {
+			route := n.explicit[idx]
+			base := (idx - (1 << route.length)) << (artStrideBits - int(route.length))
+			bytes[byteOffset] = byte(base)
+
+			totalLen := byteOffset*artStrideBits + int(route.length)
+			if !fn(artBytesToPrefix(bytes[:byteOffset+1], totalLen, isIPv4), route.nextHop) {
+				return false
+			}
+		}
+	}
+
+	for v, child := range n.children {
+		if child == nil {
+			continue
+		}
+		bytes[byteOffset] = byte(v)
+		if !child.walk(bytes, byteOffset+1, isIPv4, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// artBytesToPrefix builds a netip.Prefix from the first totalLen bits of
+// bytes, zero-padding the rest of the address.
+func artBytesToPrefix(bytes []byte, totalLen int, isIPv4 bool) netip.Prefix {
+	if isIPv4 {
+		var b [4]byte
+		copy(b[:], bytes)
+		return netip.PrefixFrom(netip.AddrFrom4(b), totalLen)
+	}
+	var b [16]byte
+	copy(b[:], bytes)
+	return netip.PrefixFrom(netip.AddrFrom16(b), totalLen)
+}
+
+// artNodeApproxBytes estimates one artNode's memory footprint: the
+// fixed-size stride arrays dominate, while the variable-sized explicit
+// map is counted separately since Go map bucket overhead isn't part of
+// the struct's own size.
+func artNodeApproxBytes(n *artNode) uint64 {
+	const mapEntryOverhead = 48 // rough per-entry cost of a Go map[int]artRoute bucket
+	return uint64(unsafe.Sizeof(*n)) + uint64(len(n.explicit))*mapEntryOverhead
+}
+
+// artBatchBackend is the BackendPureGo implementation of batchBackend: a
+// cgo-free multi-level ART. It trades the C trie's raw throughput on huge
+// batches for the absence of any cgo call overhead, which dominates for
+// small batches and single-shot Insert/Lookup.
+type artBatchBackend struct {
+	root   *artNode
+	gen    uint64
+	isIPv4 bool
+
+	// immutable marks a backend returned by snapshot(): it shares nodes
+	// with the live backend it was taken from and only supports lookups.
+	immutable bool
+}
+
+func newARTBatchBackend(isIPv4 bool) *artBatchBackend {
+	return &artBatchBackend{root: newARTNode(1), gen: 1, isIPv4: isIPv4}
+}
+
+func (b *artBatchBackend) close() error {
+	b.root = nil
+	return nil
+}
+
+// checkMutable rejects writes against a backend returned by snapshot().
+func (b *artBatchBackend) checkMutable() error {
+	if b.immutable {
+		return errors.New("liblpm: cannot mutate a Snapshot")
+	}
+	return nil
+}
+
+func (b *artBatchBackend) insert(prefix netip.Prefix, nextHop NextHop) error {
+	if err := b.checkMutable(); err != nil {
+		return err
+	}
+
+	bytes, length, err := prefixToBytes(prefix)
+	if err != nil {
+		return err
+	}
+
+	b.root = b.root.insert(bytes, 0, int(length), nextHop, b.gen)
+	return nil
+}
+
+func (b *artBatchBackend) delete(prefix netip.Prefix) (NextHop, bool, error) {
+	if err := b.checkMutable(); err != nil {
+		return InvalidNextHop, false, err
+	}
+
+	bytes, length, err := prefixToBytes(prefix)
+	if err != nil {
+		return InvalidNextHop, false, err
+	}
+
+	newRoot, prevNextHop, existed := b.root.delete(bytes, 0, int(length), b.gen)
+	b.root = newRoot
+	return prevNextHop, existed, nil
+}
+
+func (b *artBatchBackend) batchDelete(prefixes []netip.Prefix) ([]NextHop, error) {
+	prevNextHops := make([]NextHop, len(prefixes))
+	for i, prefix := range prefixes {
+		prevNextHop, existed, err := b.delete(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if existed {
+			prevNextHops[i] = prevNextHop
+		} else {
+			prevNextHops[i] = InvalidNextHop
+		}
+	}
+	return prevNextHops, nil
+}
+
+func (b *artBatchBackend) update(prefix netip.Prefix, newNextHop NextHop) error {
+	// A route is just a single painted entry keyed by (value, length), so
+	// re-inserting in place is already the atomic replace - no separate
+	// lookup or delete is needed.
+	return b.insert(prefix, newNextHop)
+}
+
+func (b *artBatchBackend) batchInsert(prefixes []netip.Prefix, nextHops []NextHop) error {
+	for i, prefix := range prefixes {
+		if err := b.insert(prefix, nextHops[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *artBatchBackend) lookupBatch(addrs []netip.Addr) ([]NextHop, error) {
+	results := make([]NextHop, len(addrs))
+
+	for i, addr := range addrs {
+		bytes, err := addrToBytes(addr)
+		if err != nil || (b.isIPv4 && !addr.Is4()) || (!b.isIPv4 && !addr.Is6()) {
+			results[i] = InvalidNextHop
+			continue
+		}
+
+		nh, ok := b.root.lookup(bytes, 0)
+		if !ok {
+			nh = InvalidNextHop
+		}
+		results[i] = nh
+	}
+
+	return results, nil
+}
+
+func (b *artBatchBackend) lookupBatchRaw(addrsU32 []uint32, results []uint32) error {
+	var bytes [4]byte
+	for i, a := range addrsU32 {
+		binary.BigEndian.PutUint32(bytes[:], a)
+		nh, ok := b.root.lookup(bytes[:], 0)
+		if !ok {
+			nh = InvalidNextHop
+		}
+		results[i] = uint32(nh)
+	}
+	return nil
+}
+
+func (b *artBatchBackend) walk(fn func(prefix netip.Prefix, nextHop NextHop) bool) error {
+	byteLen := 4
+	if !b.isIPv4 {
+		byteLen = 16
+	}
+	bytes := make([]byte, byteLen)
+	b.root.walk(bytes, 0, b.isIPv4, fn)
+	return nil
+}
+
+func (b *artBatchBackend) stats() (BatchTableStats, error) {
+	maxLen := 32
+	if !b.isIPv4 {
+		maxLen = 128
+	}
+
+	st := BatchTableStats{
+		DepthHistogram:   make([]uint64, 0),
+		PrefixesByLength: make([]uint64, maxLen+1),
+	}
+
+	var walkNode func(n *artNode, depth int)
+	walkNode = func(n *artNode, depth int) {
+		st.NumNodes++
+		st.MemoryUsageKB += artNodeApproxBytes(n)
+		for len(st.DepthHistogram) <= depth {
+			st.DepthHistogram = append(st.DepthHistogram, 0)
+		}
+		st.DepthHistogram[depth]++
+
+		for _, route := range n.explicit {
+			st.NumPrefixes++
+			totalLen := depth*artStrideBits + int(route.length)
+			if totalLen >= 0 && totalLen < len(st.PrefixesByLength) {
+				st.PrefixesByLength[totalLen]++
+			}
+		}
+
+		for _, child := range n.children {
+			if child != nil {
+				walkNode(child, depth+1)
+			}
+		}
+	}
+	walkNode(b.root, 0)
+
+	st.MemoryUsageKB = (st.MemoryUsageKB + 1023) / 1024
+	return st, nil
+}
+
+// snapshot takes an O(1) copy-on-write view of the tree: it shares b's
+// current root with the returned backend and bumps b's own generation,
+// so any later mutation on b path-copies only the nodes it actually
+// touches instead of the whole tree. The snapshot itself is read-only.
+func (b *artBatchBackend) snapshot() (batchBackend, error) {
+	snap := &artBatchBackend{root: b.root, gen: b.gen, isIPv4: b.isIPv4, immutable: true}
+	b.gen++
+	return snap, nil
+}
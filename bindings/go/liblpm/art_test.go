@@ -0,0 +1,250 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestPureGoBackendIPv4LookupMatchesCGO exercises the BackendPureGo ART
+// implementation against the same scenarios used for the cgo trie.
+func TestPureGoBackendIPv4LookupMatchesCGO(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []struct {
+		prefix  string
+		nextHop NextHop
+	}{
+		{"10.0.0.0/8", 100},
+		{"10.1.0.0/16", 200},
+		{"10.1.1.0/24", 300},
+	}
+
+	for _, p := range prefixes {
+		if err := table.Insert(netip.MustParsePrefix(p.prefix), p.nextHop); err != nil {
+			t.Fatalf("Failed to insert %s: %v", p.prefix, err)
+		}
+	}
+
+	tests := []struct {
+		addr string
+		want NextHop
+	}{
+		{"10.1.1.1", 300},
+		{"10.1.2.1", 200},
+		{"10.2.1.1", 100},
+		{"11.0.0.1", InvalidNextHop},
+	}
+
+	for _, tt := range tests {
+		results, err := table.LookupBatch([]netip.Addr{netip.MustParseAddr(tt.addr)})
+		if err != nil {
+			t.Fatalf("LookupBatch failed: %v", err)
+		}
+		if results[0] != tt.want {
+			t.Errorf("Lookup %s: want %d, got %d", tt.addr, tt.want, results[0])
+		}
+	}
+}
+
+// TestPureGoBackendDefaultRoute verifies a /0 default route is honored.
+func TestPureGoBackendDefaultRoute(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("0.0.0.0/0"), 999); err != nil {
+		t.Fatalf("Failed to insert default route: %v", err)
+	}
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/8"), 100); err != nil {
+		t.Fatalf("Failed to insert specific route: %v", err)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{
+		netip.MustParseAddr("10.1.1.1"),
+		netip.MustParseAddr("8.8.8.8"),
+	})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != 100 {
+		t.Errorf("Expected specific route to win, got %d", results[0])
+	}
+	if results[1] != 999 {
+		t.Errorf("Expected default route for 8.8.8.8, got %d", results[1])
+	}
+}
+
+// TestPureGoBackendIPv6 checks the 16-level IPv6 decomposition.
+func TestPureGoBackendIPv6(t *testing.T) {
+	table, err := NewBatchTableIPv6WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("2001:db8::/32"), 100); err != nil {
+		t.Fatalf("Failed to insert prefix: %v", err)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{
+		netip.MustParseAddr("2001:db8::1"),
+		netip.MustParseAddr("2001:db9::1"),
+	})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != 100 {
+		t.Errorf("Expected match for 2001:db8::1, got %d", results[0])
+	}
+	if results[1] != InvalidNextHop {
+		t.Errorf("Expected no match for 2001:db9::1, got %d", results[1])
+	}
+}
+
+// TestPureGoBackendDelete verifies that Delete restores the covering
+// less-specific route after a more-specific one is removed.
+func TestPureGoBackendDelete(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	wide := netip.MustParsePrefix("10.0.0.0/8")
+	narrow := netip.MustParsePrefix("10.1.1.0/24")
+
+	if err := table.Insert(wide, 100); err != nil {
+		t.Fatalf("Failed to insert %s: %v", wide, err)
+	}
+	if err := table.Insert(narrow, 300); err != nil {
+		t.Fatalf("Failed to insert %s: %v", narrow, err)
+	}
+
+	prev, existed, err := table.Delete(narrow)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if !existed || prev != 300 {
+		t.Fatalf("Expected prior next hop 300, got %d (existed=%v)", prev, existed)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{netip.MustParseAddr("10.1.1.1")})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != 100 {
+		t.Errorf("Expected fallback to /8 route (100) after delete, got %d", results[0])
+	}
+
+	// Deleting an absent prefix reports existed=false.
+	_, existed, err = table.Delete(narrow)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if existed {
+		t.Error("Expected existed=false for an already-deleted prefix")
+	}
+}
+
+// TestPureGoBackendBatchDelete exercises BatchDelete across several
+// prefixes at once.
+func TestPureGoBackendBatchDelete(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+	}
+	nextHops := []NextHop{1, 2}
+	if err := table.BatchInsert(prefixes, nextHops); err != nil {
+		t.Fatalf("BatchInsert failed: %v", err)
+	}
+
+	prevHops, err := table.BatchDelete(prefixes)
+	if err != nil {
+		t.Fatalf("BatchDelete failed: %v", err)
+	}
+	if prevHops[0] != 1 || prevHops[1] != 2 {
+		t.Errorf("Unexpected prior next hops: %v", prevHops)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{
+		netip.MustParseAddr("192.168.1.1"),
+		netip.MustParseAddr("172.16.5.5"),
+	})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != InvalidNextHop || results[1] != InvalidNextHop {
+		t.Errorf("Expected both routes gone after BatchDelete, got %v", results)
+	}
+}
+
+// TestPureGoBackendUpdate verifies Update replaces the next hop in place.
+func TestPureGoBackendUpdate(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/8")
+	if err := table.Insert(prefix, 100); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if err := table.Update(prefix, 200); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{netip.MustParseAddr("10.1.1.1")})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != 200 {
+		t.Errorf("Expected updated next hop 200, got %d", results[0])
+	}
+}
+
+// TestBatchInsertWithPureGoBackend exercises BatchInsert against the
+// pure-Go backend.
+func TestBatchInsertWithPureGoBackend(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+	}
+	nextHops := []NextHop{1, 2}
+
+	if err := table.BatchInsert(prefixes, nextHops); err != nil {
+		t.Fatalf("BatchInsert failed: %v", err)
+	}
+
+	addrsU32 := make([]uint32, 2)
+	addr1 := netip.MustParseAddr("192.168.1.1").As4()
+	addr2 := netip.MustParseAddr("172.16.5.5").As4()
+	addrsU32[0] = uint32(addr1[0])<<24 | uint32(addr1[1])<<16 | uint32(addr1[2])<<8 | uint32(addr1[3])
+	addrsU32[1] = uint32(addr2[0])<<24 | uint32(addr2[1])<<16 | uint32(addr2[2])<<8 | uint32(addr2[3])
+
+	results := make([]uint32, 2)
+	if err := table.LookupBatchRaw(addrsU32, results); err != nil {
+		t.Fatalf("LookupBatchRaw failed: %v", err)
+	}
+	if NextHop(results[0]) != 1 || NextHop(results[1]) != 2 {
+		t.Errorf("Unexpected results: %v", results)
+	}
+}
@@ -3,79 +3,245 @@ package liblpm
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net/netip"
 	"runtime"
+	"strings"
 )
 
+// Backend selects the underlying implementation used by a BatchTable.
+type Backend int
+
+const (
+	// BackendCGO drives the C liblpm trie through cgo. This is the
+	// default and offers the best throughput for large batches.
+	BackendCGO Backend = iota
+	// BackendPureGo uses a cgo-free multi-level ART (Allotment Routing
+	// Table) implemented entirely in Go. It avoids cgo call overhead,
+	// which matters most for small batches and single-shot Insert/Lookup.
+	BackendPureGo
+)
+
+// batchBackend is the strategy interface implemented by each BatchTable
+// execution engine (cgo trie, pure-Go ART, ...). It only ever deals in
+// uint32 handles (carried as NextHop); BatchTable[V] is responsible for
+// mapping those handles to and from arbitrary values.
+type batchBackend interface {
+	insert(prefix netip.Prefix, nextHop NextHop) error
+	batchInsert(prefixes []netip.Prefix, nextHops []NextHop) error
+	delete(prefix netip.Prefix) (NextHop, bool, error)
+	batchDelete(prefixes []netip.Prefix) ([]NextHop, error)
+	update(prefix netip.Prefix, newNextHop NextHop) error
+	lookupBatch(addrs []netip.Addr) ([]NextHop, error)
+	lookupBatchRaw(addrsU32 []uint32, results []uint32) error
+	snapshot() (batchBackend, error)
+	walk(fn func(prefix netip.Prefix, nextHop NextHop) bool) error
+	stats() (BatchTableStats, error)
+	close() error
+}
+
+// BatchTableStats summarizes a BatchTable's current trie shape and
+// approximate memory footprint, for capacity planning and for reporting
+// memory-per-route alongside ns/lookup in benchmarks. Only BackendPureGo
+// can report it - see Walk.
+type BatchTableStats struct {
+	NumPrefixes   uint64
+	NumNodes      uint64
+	MemoryUsageKB uint64
+
+	// DepthHistogram[d] is the number of trie nodes at depth d (root is
+	// depth 0).
+	DepthHistogram []uint64
+
+	// PrefixesByLength[n] is the number of installed prefixes of length
+	// n, indexed 0..32 for IPv4 or 0..128 for IPv6.
+	PrefixesByLength []uint64
+}
+
 // BatchTable is an optimized routing table focused on batch operations.
 // It uses zero-copy techniques with Go 1.21+ pinning for maximum performance.
 // This is the recommended API for high-performance routing applications.
-type BatchTable struct {
-	cTrie  uintptr
-	closed bool
-	isIPv4 bool
+//
+// BatchTable is parameterized over the value stored per prefix. The C (or
+// pure-Go ART) trie underneath only ever stores a uint32 handle; when V is
+// NextHop that handle *is* the value, so BatchTable[NextHop] - what every
+// constructor in this file returns, and what existing callers get via `:=`
+// type inference - behaves exactly like the original uint32-only table,
+// including the zero-copy LookupBatchRaw fast path. For any other V, the
+// handle instead indexes a Go-side slab of values, recycled on Delete, so
+// callers can associate arbitrary route metadata (an *ACL, a path-attr
+// struct, ...) with a prefix without maintaining a parallel map themselves.
+// Use NewBatchTableIPv4Generic / NewBatchTableIPv6Generic to pick a V other
+// than NextHop.
+//
+// This makes the exported BatchTable identifier itself generic, rather than
+// keeping BatchTable as a plain uint32-only type and adding a separate
+// generic type alongside it (the route taken for Table/GenericTable[V]).
+// Go has no way to alias a name to one instantiation of its own generic
+// type, so BatchTable couldn't stay non-generic while also being
+// BatchTable[NextHop] under the hood; parameterizing it directly was the
+// only option. That makes this a source-breaking change for any existing
+// code spelling out `*BatchTable` as a field or parameter type - such code
+// must become `*BatchTable[liblpm.NextHop]` - though call sites using `:=`
+// type inference on a constructor are unaffected.
+type BatchTable[V any] struct {
+	backend  batchBackend
+	closed   bool
+	isIPv4   bool
+	identity bool // true when V == NextHop: the handle IS the value.
+
+	slab []V
+	free []uint32
 }
 
 // NewBatchTableIPv4 creates a new batch-optimized IPv4 routing table.
 // This table is optimized for batch insert and batch lookup operations.
-func NewBatchTableIPv4() (*BatchTable, error) {
-	triePtr, err := cCreateIPv4()
+func NewBatchTableIPv4() (*BatchTable[NextHop], error) {
+	return NewBatchTableIPv4WithBackend(BackendCGO)
+}
+
+// NewBatchTableIPv6 creates a new batch-optimized IPv6 routing table.
+func NewBatchTableIPv6() (*BatchTable[NextHop], error) {
+	return NewBatchTableIPv6WithBackend(BackendCGO)
+}
+
+// NewBatchTableIPv4WithBackend creates a batch-optimized IPv4 routing table
+// using the requested execution backend. BackendCGO (the default) delegates
+// to the C trie; BackendPureGo uses a cgo-free multi-level ART instead,
+// which removes cgo crossing overhead at the cost of some peak throughput.
+func NewBatchTableIPv4WithBackend(backend Backend) (*BatchTable[NextHop], error) {
+	return newBatchTable[NextHop](backend, true)
+}
+
+// NewBatchTableIPv6WithBackend creates a batch-optimized IPv6 routing table
+// using the requested execution backend. See NewBatchTableIPv4WithBackend.
+func NewBatchTableIPv6WithBackend(backend Backend) (*BatchTable[NextHop], error) {
+	return newBatchTable[NextHop](backend, false)
+}
+
+// NewBatchTableIPv4Generic creates a batch-optimized IPv4 table that stores
+// an arbitrary value V per prefix instead of a bare NextHop.
+func NewBatchTableIPv4Generic[V any](backend Backend) (*BatchTable[V], error) {
+	return newBatchTable[V](backend, true)
+}
+
+// NewBatchTableIPv6Generic creates a batch-optimized IPv6 table that stores
+// an arbitrary value V per prefix instead of a bare NextHop.
+func NewBatchTableIPv6Generic[V any](backend Backend) (*BatchTable[V], error) {
+	return newBatchTable[V](backend, false)
+}
+
+func newBatchTable[V any](backend Backend, isIPv4 bool) (*BatchTable[V], error) {
+	b, err := newBatchBackend(backend, isIPv4)
 	if err != nil {
 		return nil, err
 	}
 
-	t := &BatchTable{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: true,
-	}
+	var zero V
+	_, identity := any(zero).(NextHop)
 
-	runtime.SetFinalizer(t, (*BatchTable).finalize)
+	t := &BatchTable[V]{backend: b, isIPv4: isIPv4, identity: identity}
+	runtime.SetFinalizer(t, (*BatchTable[V]).finalize)
 	return t, nil
 }
 
-// NewBatchTableIPv6 creates a new batch-optimized IPv6 routing table.
-func NewBatchTableIPv6() (*BatchTable, error) {
-	triePtr, err := cCreateIPv6()
-	if err != nil {
-		return nil, err
+// newBatchBackend constructs the backend implementation for the requested
+// Backend and address family.
+func newBatchBackend(backend Backend, isIPv4 bool) (batchBackend, error) {
+	switch backend {
+	case BackendCGO:
+		return newCGOBatchBackend(isIPv4)
+	case BackendPureGo:
+		return newARTBatchBackend(isIPv4), nil
+	default:
+		return nil, errors.New("liblpm: unknown backend")
 	}
+}
 
-	t := &BatchTable{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: false,
+// handleFor returns the uint32 handle that should be stored in the trie
+// for value v, allocating (or reusing) a slab slot when V isn't NextHop.
+func (t *BatchTable[V]) handleFor(v V) uint32 {
+	if t.identity {
+		return uint32(any(v).(NextHop))
 	}
 
-	runtime.SetFinalizer(t, (*BatchTable).finalize)
-	return t, nil
+	if n := len(t.free); n > 0 {
+		h := t.free[n-1]
+		t.free = t.free[:n-1]
+		t.slab[h] = v
+		return h
+	}
+
+	h := uint32(len(t.slab))
+	t.slab = append(t.slab, v)
+	return h
+}
+
+// valueFor resolves a trie handle back to its value.
+func (t *BatchTable[V]) valueFor(h uint32) V {
+	if t.identity {
+		return any(NextHop(h)).(V)
+	}
+	return t.slab[h]
+}
+
+// releaseHandle recycles a handle's slab slot after its route is deleted.
+func (t *BatchTable[V]) releaseHandle(h uint32) {
+	if t.identity {
+		return
+	}
+	var zero V
+	t.slab[h] = zero
+	t.free = append(t.free, h)
+}
+
+// reclaimExisting releases the slab slot of any value already stored at
+// prefix, if one exists. The backend's insert has no way to hand back the
+// handle it's about to overwrite, so without this an Insert/BatchInsert
+// into an already-occupied prefix would orphan its old slab slot forever.
+// Only non-identity tables have a slab to leak, so this is a no-op when
+// V is NextHop.
+func (t *BatchTable[V]) reclaimExisting(prefix netip.Prefix) {
+	if t.identity {
+		return
+	}
+	if prevHandle, existed, err := t.backend.delete(prefix); err == nil && existed {
+		t.releaseHandle(uint32(prevHandle))
+	}
 }
 
-func (t *BatchTable) finalize() {
-	if !t.closed && t.cTrie != 0 {
-		cDestroy(t.cTrie)
-		t.cTrie = 0
+// noMatchValue is what a "no route matched" handle resolves to: when V is
+// NextHop, that's InvalidNextHop (matching the original uint32 API); for
+// any other V there's no slab slot for it, so it's just V's zero value.
+func (t *BatchTable[V]) noMatchValue() V {
+	if t.identity {
+		return any(NextHop(InvalidNextHop)).(V)
+	}
+	var zero V
+	return zero
+}
+
+func (t *BatchTable[V]) finalize() {
+	if !t.closed {
+		t.backend.close()
 		t.closed = true
 	}
 }
 
 // Close releases all resources.
-func (t *BatchTable) Close() error {
+func (t *BatchTable[V]) Close() error {
 	if t.closed {
 		return nil
 	}
 
-	if t.cTrie != 0 {
-		cDestroy(t.cTrie)
-		t.cTrie = 0
-	}
+	err := t.backend.close()
 	t.closed = true
 	runtime.SetFinalizer(t, nil)
-	return nil
+	return err
 }
 
 // Insert adds a single prefix (uses optimized zero-copy).
-func (t *BatchTable) Insert(prefix netip.Prefix, nextHop NextHop) error {
+func (t *BatchTable[V]) Insert(prefix netip.Prefix, value V) error {
 	if t.closed {
 		return ErrTableClosed
 	}
@@ -87,136 +253,297 @@ func (t *BatchTable) Insert(prefix netip.Prefix, nextHop NextHop) error {
 		return ErrInvalidPrefix
 	}
 
-	prefixBytes, prefixLen, err := prefixToBytes(prefix)
-	if err != nil {
+	t.reclaimExisting(prefix)
+
+	handle := t.handleFor(value)
+	if err := t.backend.insert(prefix, NextHop(handle)); err != nil {
+		t.releaseHandle(handle)
 		return err
 	}
-
-	return cAdd(t.cTrie, prefixBytes, prefixLen, uint32(nextHop))
+	return nil
 }
 
 // BatchInsert inserts multiple prefixes in one operation.
 // This amortizes cgo overhead across all inserts!
-func (t *BatchTable) BatchInsert(prefixes []netip.Prefix, nextHops []NextHop) error {
+func (t *BatchTable[V]) BatchInsert(prefixes []netip.Prefix, values []V) error {
 	if t.closed {
 		return ErrTableClosed
 	}
-	if len(prefixes) != len(nextHops) {
-		return errors.New("prefixes and nextHops length mismatch")
+	if len(prefixes) != len(values) {
+		return errors.New("prefixes and values length mismatch")
 	}
 	if len(prefixes) == 0 {
 		return nil
 	}
 
-	// Prepare data for batch insert
-	prefixBytes := make([][]byte, len(prefixes))
-	prefixLens := make([]uint8, len(prefixes))
-	nextHopsU32 := make([]uint32, len(prefixes))
-
-	for i, prefix := range prefixes {
+	for _, prefix := range prefixes {
 		if t.isIPv4 && !prefix.Addr().Is4() {
 			return ErrInvalidPrefix
 		}
 		if !t.isIPv4 && !prefix.Addr().Is6() {
 			return ErrInvalidPrefix
 		}
+	}
+
+	handles := make([]NextHop, len(values))
+	for i, v := range values {
+		t.reclaimExisting(prefixes[i])
+		handles[i] = NextHop(t.handleFor(v))
+	}
 
-		bytes, plen, err := prefixToBytes(prefix)
-		if err != nil {
-			return err
+	if err := t.backend.batchInsert(prefixes, handles); err != nil {
+		for _, h := range handles {
+			t.releaseHandle(uint32(h))
 		}
-		prefixBytes[i] = bytes
-		prefixLens[i] = plen
-		nextHopsU32[i] = uint32(nextHops[i])
+		return err
+	}
+	return nil
+}
+
+// Delete removes a single prefix and reports the value it previously
+// held, so FIB churn doesn't need a separate Lookup before tearing down
+// a route.
+func (t *BatchTable[V]) Delete(prefix netip.Prefix) (V, bool, error) {
+	var zero V
+	if t.closed {
+		return zero, false, ErrTableClosed
 	}
 
-	return cBatchInsertIPv4(t.cTrie, prefixBytes, prefixLens, nextHopsU32)
+	if t.isIPv4 && !prefix.Addr().Is4() {
+		return zero, false, ErrInvalidPrefix
+	}
+	if !t.isIPv4 && !prefix.Addr().Is6() {
+		return zero, false, ErrInvalidPrefix
+	}
+
+	prevHandle, existed, err := t.backend.delete(prefix)
+	if err != nil || !existed {
+		return zero, existed, err
+	}
+
+	value := t.valueFor(uint32(prevHandle))
+	t.releaseHandle(uint32(prevHandle))
+	return value, true, nil
 }
 
-// LookupBatch performs batch lookups with zero-copy optimization.
-// This is the primary method for high-performance routing.
-// For IPv4, this achieves ~1.8ns per lookup!
-func (t *BatchTable) LookupBatch(addrs []netip.Addr) ([]NextHop, error) {
+// BatchDelete removes multiple prefixes in one operation, amortizing cgo
+// overhead the same way BatchInsert does, and returns the prior value
+// for each entry. An entry whose prefix wasn't present gets InvalidNextHop
+// when V is NextHop, and the zero value of V otherwise.
+func (t *BatchTable[V]) BatchDelete(prefixes []netip.Prefix) ([]V, error) {
 	if t.closed {
 		return nil, ErrTableClosed
 	}
-	if len(addrs) == 0 {
-		return []NextHop{}, nil
+	if len(prefixes) == 0 {
+		return []V{}, nil
 	}
 
-	results := make([]uint32, len(addrs))
-
-	if t.isIPv4 {
-		// Convert addresses to uint32 array (zero allocation after first call)
-		addrsU32 := make([]uint32, len(addrs))
-		for i, addr := range addrs {
-			if !addr.Is4() {
-				results[i] = uint32(InvalidNextHop)
-				continue
-			}
-			addr4 := addr.As4()
-			addrsU32[i] = binary.BigEndian.Uint32(addr4[:])
+	for _, prefix := range prefixes {
+		if t.isIPv4 && !prefix.Addr().Is4() {
+			return nil, ErrInvalidPrefix
 		}
-
-		// Zero-copy batch lookup!
-		err := cLookupBatchIPv4(t.cTrie, addrsU32, results)
-		if err != nil {
-			return nil, err
+		if !t.isIPv4 && !prefix.Addr().Is6() {
+			return nil, ErrInvalidPrefix
 		}
-	} else {
-		// IPv6 batch lookup
-		addrs16 := make([][16]byte, len(addrs))
-		for i, addr := range addrs {
-			if !addr.Is6() {
-				results[i] = uint32(InvalidNextHop)
-				continue
-			}
-			addrs16[i] = addr.As16()
+	}
+
+	prevHandles, err := t.backend.batchDelete(prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]V, len(prevHandles))
+	for i, h := range prevHandles {
+		if h == InvalidNextHop {
+			values[i] = t.noMatchValue()
+			continue
 		}
+		values[i] = t.valueFor(uint32(h))
+		t.releaseHandle(uint32(h))
+	}
+	return values, nil
+}
+
+// Update atomically replaces the value stored for an existing prefix.
+// Since a route is just a single mapping from prefix to handle,
+// re-inserting in place already performs the replacement in one call,
+// without a separate lookup+insert round trip.
+func (t *BatchTable[V]) Update(prefix netip.Prefix, newValue V) error {
+	return t.Insert(prefix, newValue)
+}
+
+// Snapshot returns an immutable, cheaply-cloned view of the current
+// table that concurrent goroutines can LookupBatch against with no
+// locking, while the original table keeps accepting Insert/Delete. It's
+// backed by copy-on-write at the trie-node level (BackendPureGo): taking
+// a snapshot is O(1), since it only shares the current root with the
+// live table and bumps a generation counter - later mutations path-copy
+// just the nodes they touch, leaving the rest of the tree, and therefore
+// the snapshot's view of it, untouched. Data-plane lookup goroutines can
+// then swap in the latest Snapshot after each control-plane batch
+// update, the standard RCU-style pattern for BGP FIB installers.
+//
+// Snapshot requires BatchTable[NextHop] and BackendPureGo. For any other
+// V the trie only ever holds a handle into this table's Go-side value
+// slab, and the slab isn't itself copy-on-write, so a generic-V snapshot
+// could observe a handle recycled by a concurrent Delete on the live
+// table. BackendCGO can't support it either: true node-level COW needs
+// refcounted nodes in the C trie, which this build doesn't have.
+//
+// The returned table must be closed like any other; it does not need
+// (and does not support) Insert/Delete/Update.
+func (t *BatchTable[V]) Snapshot() (*BatchTable[V], error) {
+	if t.closed {
+		return nil, ErrTableClosed
+	}
+	if !t.identity {
+		return nil, errors.New("Snapshot requires BatchTable[NextHop]")
+	}
 
-		err := cLookupBatchIPv6(t.cTrie, addrs16, results)
-		if err != nil {
-			return nil, err
+	snapBackend, err := t.backend.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &BatchTable[V]{backend: snapBackend, isIPv4: t.isIPv4, identity: t.identity}
+	runtime.SetFinalizer(snap, (*BatchTable[V]).finalize)
+	return snap, nil
+}
+
+// Walk iterates every explicitly installed prefix in tree order, calling
+// fn with its value. Iteration stops early if fn returns false. Needed
+// for FIB dumps and config-reload diffs. Only BackendPureGo supports
+// Walk - the cgo trie's internal nodes aren't exposed to Go.
+//
+// Walk is not safe to call concurrently with Insert/Delete/Update on the
+// same live table: those mutate a node's route map in place whenever
+// its generation matches the table's current one. Take a Snapshot first
+// if a control-plane goroutine keeps writing while Walk runs.
+func (t *BatchTable[V]) Walk(fn func(prefix netip.Prefix, value V) bool) error {
+	if t.closed {
+		return ErrTableClosed
+	}
+
+	return t.backend.walk(func(prefix netip.Prefix, h NextHop) bool {
+		return fn(prefix, t.valueFor(uint32(h)))
+	})
+}
+
+// Stats reports the table's current trie shape and approximate memory
+// footprint, for capacity planning and for reporting memory-per-route
+// alongside ns/lookup in benchmarks. Only BackendPureGo supports Stats.
+// The same concurrency caveat as Walk applies: prefer a Snapshot over
+// calling Stats against a table still being mutated.
+func (t *BatchTable[V]) Stats() (BatchTableStats, error) {
+	if t.closed {
+		return BatchTableStats{}, ErrTableClosed
+	}
+	return t.backend.stats()
+}
+
+// DebugSummary renders the trie shape for troubleshooting, similar to
+// Tailscale's net/art Table.debugSummary.
+func (t *BatchTable[V]) DebugSummary() string {
+	if t.closed {
+		return "BatchTable: closed"
+	}
+
+	st, err := t.backend.stats()
+	if err != nil {
+		return fmt.Sprintf("BatchTable: stats unavailable (%v)", err)
+	}
+
+	family := "IPv6"
+	if t.isIPv4 {
+		family = "IPv4"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "BatchTable (%s)\n", family)
+	fmt.Fprintf(&b, "  prefixes: %d\n", st.NumPrefixes)
+	fmt.Fprintf(&b, "  nodes:    %d\n", st.NumNodes)
+	fmt.Fprintf(&b, "  memory:   %d KB\n", st.MemoryUsageKB)
+	fmt.Fprintf(&b, "  depth histogram: %v\n", st.DepthHistogram)
+
+	fmt.Fprint(&b, "  prefixes by length:")
+	wroteAny := false
+	for length, count := range st.PrefixesByLength {
+		if count == 0 {
+			continue
 		}
+		fmt.Fprintf(&b, " /%d=%d", length, count)
+		wroteAny = true
 	}
+	if !wroteAny {
+		fmt.Fprint(&b, " (none)")
+	}
+	b.WriteByte('\n')
+
+	return b.String()
+}
 
-	// Convert to NextHop slice
-	nextHops := make([]NextHop, len(results))
-	for i, r := range results {
-		nextHops[i] = NextHop(r)
+// LookupBatch performs batch lookups with zero-copy optimization.
+// This is the primary method for high-performance routing.
+// For IPv4, this achieves ~1.8ns per lookup!
+func (t *BatchTable[V]) LookupBatch(addrs []netip.Addr) ([]V, error) {
+	if t.closed {
+		return nil, ErrTableClosed
+	}
+	if len(addrs) == 0 {
+		return []V{}, nil
+	}
+
+	handles, err := t.backend.lookupBatch(addrs)
+	if err != nil {
+		return nil, err
 	}
 
-	return nextHops, nil
+	values := make([]V, len(handles))
+	for i, h := range handles {
+		if h == InvalidNextHop {
+			values[i] = t.noMatchValue()
+			continue
+		}
+		values[i] = t.valueFor(uint32(h))
+	}
+	return values, nil
 }
 
 // LookupBatchRaw performs batch lookups using pre-converted uint32 addresses.
-// This is the absolute fastest method - no conversion overhead!
+// This is the absolute fastest method - no conversion overhead! It requires
+// V to be NextHop, since the results buffer holds raw handles directly.
 // Use this when you already have addresses as uint32 (network byte order).
-func (t *BatchTable) LookupBatchRaw(addrsU32 []uint32, results []uint32) error {
+func (t *BatchTable[V]) LookupBatchRaw(addrsU32 []uint32, results []uint32) error {
 	if t.closed {
 		return ErrTableClosed
 	}
 	if !t.isIPv4 {
 		return errors.New("LookupBatchRaw only supported for IPv4")
 	}
+	if !t.identity {
+		return errors.New("LookupBatchRaw requires BatchTable[NextHop]")
+	}
 	if len(results) < len(addrsU32) {
 		return errors.New("results slice too small")
 	}
 
-	// Direct zero-copy call - this is as fast as it gets!
-	return cLookupBatchIPv4(t.cTrie, addrsU32, results)
+	return t.backend.lookupBatchRaw(addrsU32, results)
 }
 
 // PreallocatedBatchLookup performs batch lookups using caller-provided buffers.
 // This eliminates ALL allocations for maximum performance.
-// Reuse the same buffers across multiple calls for best results.
-func (t *BatchTable) PreallocatedBatchLookup(addrs []netip.Addr, addrsU32 []uint32, results []uint32) error {
+// Reuse the same buffers across multiple calls for best results. Like
+// LookupBatchRaw, it requires V to be NextHop.
+func (t *BatchTable[V]) PreallocatedBatchLookup(addrs []netip.Addr, addrsU32 []uint32, results []uint32) error {
 	if t.closed {
 		return ErrTableClosed
 	}
 	if !t.isIPv4 {
 		return errors.New("PreallocatedBatchLookup only supported for IPv4")
 	}
+	if !t.identity {
+		return errors.New("PreallocatedBatchLookup requires BatchTable[NextHop]")
+	}
 	if len(addrsU32) < len(addrs) || len(results) < len(addrs) {
 		return errors.New("buffer too small")
 	}
@@ -231,7 +558,5 @@ func (t *BatchTable) PreallocatedBatchLookup(addrs []netip.Addr, addrsU32 []uint
 		addrsU32[i] = binary.BigEndian.Uint32(addr4[:])
 	}
 
-	// Zero-copy batch lookup
-	return cLookupBatchIPv4(t.cTrie, addrsU32[:len(addrs)], results[:len(addrs)])
+	return t.backend.lookupBatchRaw(addrsU32[:len(addrs)], results[:len(addrs)])
 }
-
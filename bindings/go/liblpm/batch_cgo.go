@@ -0,0 +1,190 @@
+package liblpm
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+)
+
+// cgoBatchBackend drives a BatchTable through the C liblpm trie. It is the
+// original BatchTable implementation, extracted verbatim into the
+// batchBackend interface so BackendPureGo can be selected alongside it.
+type cgoBatchBackend struct {
+	cTrie  uintptr
+	isIPv4 bool
+}
+
+func newCGOBatchBackend(isIPv4 bool) (*cgoBatchBackend, error) {
+	var triePtr uintptr
+	var err error
+	if isIPv4 {
+		triePtr, err = cCreateIPv4()
+	} else {
+		triePtr, err = cCreateIPv6()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cgoBatchBackend{cTrie: triePtr, isIPv4: isIPv4}, nil
+}
+
+func (b *cgoBatchBackend) close() error {
+	if b.cTrie != 0 {
+		cDestroy(b.cTrie)
+		b.cTrie = 0
+	}
+	return nil
+}
+
+func (b *cgoBatchBackend) insert(prefix netip.Prefix, nextHop NextHop) error {
+	prefixBytes, prefixLen, err := prefixToBytes(prefix)
+	if err != nil {
+		return err
+	}
+
+	return cAdd(b.cTrie, prefixBytes, prefixLen, uint32(nextHop))
+}
+
+func (b *cgoBatchBackend) batchInsert(prefixes []netip.Prefix, nextHops []NextHop) error {
+	prefixBytes := make([][]byte, len(prefixes))
+	prefixLens := make([]uint8, len(prefixes))
+	nextHopsU32 := make([]uint32, len(prefixes))
+
+	for i, prefix := range prefixes {
+		bytes, plen, err := prefixToBytes(prefix)
+		if err != nil {
+			return err
+		}
+		prefixBytes[i] = bytes
+		prefixLens[i] = plen
+		nextHopsU32[i] = uint32(nextHops[i])
+	}
+
+	failed := make([]bool, len(prefixes))
+	succeeded, err := cBatchInsertIPv4(b.cTrie, prefixBytes, prefixLens, nextHopsU32, failed)
+	if err != nil {
+		return err
+	}
+	if succeeded != len(prefixes) {
+		// BatchTable.BatchInsert (batch.go) releases every handle in the
+		// batch as soon as this returns an error, on the assumption that
+		// none of them made it into the trie. cBatchInsertIPv4 now keeps
+		// going past a failed entry instead of stopping at the first one
+		// (see its doc comment in cgo.go), so the prefixes it did accept
+		// have to be torn back out here, or those released handles would
+		// stay live in the C trie and get silently reused for unrelated
+		// values.
+		for i := range prefixes {
+			if !failed[i] {
+				cDelete(b.cTrie, prefixBytes[i], prefixLens[i])
+			}
+		}
+		return ErrInsertFailed
+	}
+	return nil
+}
+
+func (b *cgoBatchBackend) delete(prefix netip.Prefix) (NextHop, bool, error) {
+	prefixBytes, prefixLen, err := prefixToBytes(prefix)
+	if err != nil {
+		return InvalidNextHop, false, err
+	}
+
+	prevNextHop, existed, err := cDeleteGetPrev(b.cTrie, prefixBytes, prefixLen)
+	return NextHop(prevNextHop), existed, err
+}
+
+func (b *cgoBatchBackend) batchDelete(prefixes []netip.Prefix) ([]NextHop, error) {
+	prevNextHops := make([]NextHop, len(prefixes))
+
+	// One cgo call per prefix, same as cBatchInsertIPv4: amortizes the
+	// Go-side bookkeeping, not yet the cgo transition cost itself.
+	for i, prefix := range prefixes {
+		prefixBytes, prefixLen, err := prefixToBytes(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		prevNextHop, existed, err := cDeleteGetPrev(b.cTrie, prefixBytes, prefixLen)
+		if err != nil {
+			return nil, err
+		}
+		if existed {
+			prevNextHops[i] = NextHop(prevNextHop)
+		} else {
+			prevNextHops[i] = InvalidNextHop
+		}
+	}
+
+	return prevNextHops, nil
+}
+
+func (b *cgoBatchBackend) update(prefix netip.Prefix, newNextHop NextHop) error {
+	prefixBytes, prefixLen, err := prefixToBytes(prefix)
+	if err != nil {
+		return err
+	}
+
+	return cUpdate(b.cTrie, prefixBytes, prefixLen, uint32(newNextHop))
+}
+
+func (b *cgoBatchBackend) lookupBatch(addrs []netip.Addr) ([]NextHop, error) {
+	results := make([]uint32, len(addrs))
+
+	if b.isIPv4 {
+		// Convert addresses to uint32 array (zero allocation after first call)
+		addrsU32 := make([]uint32, len(addrs))
+		for i, addr := range addrs {
+			if !addr.Is4() {
+				results[i] = uint32(InvalidNextHop)
+				continue
+			}
+			addr4 := addr.As4()
+			addrsU32[i] = binary.BigEndian.Uint32(addr4[:])
+		}
+
+		// Zero-copy batch lookup!
+		if err := cLookupBatchIPv4(b.cTrie, addrsU32, results); err != nil {
+			return nil, err
+		}
+	} else {
+		// IPv6 batch lookup
+		addrs16 := make([][16]byte, len(addrs))
+		for i, addr := range addrs {
+			if !addr.Is6() {
+				results[i] = uint32(InvalidNextHop)
+				continue
+			}
+			addrs16[i] = addr.As16()
+		}
+
+		if err := cLookupBatchIPv6(b.cTrie, addrs16, results); err != nil {
+			return nil, err
+		}
+	}
+
+	// Convert to NextHop slice
+	nextHops := make([]NextHop, len(results))
+	for i, r := range results {
+		nextHops[i] = NextHop(r)
+	}
+
+	return nextHops, nil
+}
+
+func (b *cgoBatchBackend) lookupBatchRaw(addrsU32 []uint32, results []uint32) error {
+	return cLookupBatchIPv4(b.cTrie, addrsU32, results)
+}
+
+func (b *cgoBatchBackend) snapshot() (batchBackend, error) {
+	return nil, errors.New("liblpm: Snapshot is only supported with BackendPureGo; BackendCGO would need refcounted nodes in the C trie that this build doesn't have")
+}
+
+func (b *cgoBatchBackend) walk(fn func(prefix netip.Prefix, nextHop NextHop) bool) error {
+	return errors.New("liblpm: Walk is only supported with BackendPureGo; BackendCGO has no node enumeration without a C-side walk callback")
+}
+
+func (b *cgoBatchBackend) stats() (BatchTableStats, error) {
+	return BatchTableStats{}, errors.New("liblpm: Stats is only supported with BackendPureGo; BackendCGO has no node enumeration without a C-side walk callback")
+}
@@ -0,0 +1,139 @@
+package liblpm
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// AddBatch installs multiple prefixes on an IPv4 table using
+// cBatchInsertIPv4's pinned, zero-copy C.lpm_add loop instead of
+// InsertBatch's per-prefix calls through Insert. This tree has no
+// lpm_add_batch C entry point that takes a whole batch in one cgo crossing
+// (there's no C source here to add one to), so AddBatch is not that - it's
+// still one C.lpm_add per prefix - but it skips the malloc/copy_bytes/free
+// cBatchInsertIPv4 used to do around each of those calls, and skips
+// InsertBatch's per-prefix Go-level bookkeeping until the whole batch is
+// in.
+//
+// Unlike InsertBatch, a failure at one prefix doesn't stop the rest: every
+// prefix in the batch is attempted, and failed[i] is set for every one
+// C.lpm_add rejected (wrong length, allocation failure inside the trie,
+// and so on). added is the count that actually went in, equal to
+// len(prefixes) when failed is all-false.
+//
+// AddBatch requires an IPv4 table - cBatchInsertIPv4 has no IPv6
+// counterpart in this tree - and a table that has never called
+// InsertMultipath, since it doesn't carry InsertMultipath's group
+// bookkeeping. Use InsertBatch (or Insert) for IPv6 tables or once a table
+// has gone multipath.
+func (t *Table) AddBatch(prefixes []netip.Prefix, nextHops []NextHop) (added int, failed []uint8, err error) {
+	if t.closed {
+		return 0, nil, ErrTableClosed
+	}
+	if !t.isIPv4 {
+		return 0, nil, errors.New("liblpm: AddBatch requires an IPv4 table; cBatchInsertIPv4 has no IPv6 counterpart in this tree")
+	}
+	if t.groups != nil {
+		return 0, nil, errors.New("liblpm: AddBatch does not support a table that has used InsertMultipath; use InsertBatch instead")
+	}
+	if len(prefixes) != len(nextHops) {
+		return 0, nil, errors.New("liblpm: prefixes and nextHops must be the same length")
+	}
+	if len(prefixes) == 0 {
+		return 0, nil, nil
+	}
+
+	prefixBytes := make([][]byte, len(prefixes))
+	prefixLens := make([]uint8, len(prefixes))
+	nextHopsU32 := make([]uint32, len(prefixes))
+	for i, prefix := range prefixes {
+		if !prefix.Addr().Is4() {
+			return 0, nil, ErrInvalidPrefix
+		}
+		// See Insert's doc comment: the top bit is reserved for a
+		// multipath group reference unconditionally, not just once a
+		// table has called InsertMultipath, so a route AddBatch installs
+		// now can't be retroactively misread as a stale group reference
+		// if the table goes multipath later.
+		if uint32(nextHops[i])&multipathGroupFlag != 0 {
+			return 0, nil, errors.New("liblpm: next hop values with the top bit set are reserved for multipath group references")
+		}
+		bytes, plen, err := prefixToBytes(prefix)
+		if err != nil {
+			return 0, nil, err
+		}
+		prefixBytes[i] = bytes
+		prefixLens[i] = plen
+		nextHopsU32[i] = uint32(nextHops[i])
+	}
+
+	entryFailed := make([]bool, len(prefixes))
+	added, err = cBatchInsertIPv4(t.cTrie, prefixBytes, prefixLens, nextHopsU32, entryFailed)
+	if err != nil {
+		return added, nil, err
+	}
+
+	failed = make([]uint8, len(prefixes))
+	for i, prefix := range prefixes {
+		if entryFailed[i] {
+			failed[i] = 1
+			continue
+		}
+		t.recordRoute(prefix, nextHops[i])
+	}
+	return added, failed, nil
+}
+
+// RemoveBatch removes multiple prefixes from an IPv4 table using
+// cBatchDeleteIPv4's pinned, zero-copy C.lpm_delete loop - the AddBatch
+// counterpart for removal, under the same restrictions: IPv4 only, and
+// only for a table that has never called InsertMultipath. See AddBatch's
+// doc comment for why this still isn't a single cgo crossing for the whole
+// batch. It isn't named DeleteBatch because that name is already taken by
+// the slower, unconditional loop-over-Delete version in batch_insert.go;
+// callers pick between the two by table state, not by guessing which name
+// is which.
+func (t *Table) RemoveBatch(prefixes []netip.Prefix) (removed int, failed []uint8, err error) {
+	if t.closed {
+		return 0, nil, ErrTableClosed
+	}
+	if !t.isIPv4 {
+		return 0, nil, errors.New("liblpm: RemoveBatch requires an IPv4 table; cBatchDeleteIPv4 has no IPv6 counterpart in this tree")
+	}
+	if t.groups != nil {
+		return 0, nil, errors.New("liblpm: RemoveBatch does not support a table that has used InsertMultipath; use DeleteBatch instead")
+	}
+	if len(prefixes) == 0 {
+		return 0, nil, nil
+	}
+
+	prefixBytes := make([][]byte, len(prefixes))
+	prefixLens := make([]uint8, len(prefixes))
+	for i, prefix := range prefixes {
+		if !prefix.Addr().Is4() {
+			return 0, nil, ErrInvalidPrefix
+		}
+		bytes, plen, err := prefixToBytes(prefix)
+		if err != nil {
+			return 0, nil, err
+		}
+		prefixBytes[i] = bytes
+		prefixLens[i] = plen
+	}
+
+	entryFailed := make([]bool, len(prefixes))
+	removed, err = cBatchDeleteIPv4(t.cTrie, prefixBytes, prefixLens, entryFailed)
+	if err != nil {
+		return removed, nil, err
+	}
+
+	failed = make([]uint8, len(prefixes))
+	for i, prefix := range prefixes {
+		if entryFailed[i] {
+			failed[i] = 1
+			continue
+		}
+		t.forgetRoute(prefix)
+	}
+	return removed, failed, nil
+}
@@ -0,0 +1,173 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestAddBatchAndRemoveBatch(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+	}
+	nextHops := []NextHop{1, 2, 3}
+
+	added, failed, err := table.AddBatch(prefixes, nextHops)
+	if err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+	if added != len(prefixes) {
+		t.Errorf("added = %d, want %d", added, len(prefixes))
+	}
+	for i, f := range failed {
+		if f != 0 {
+			t.Errorf("failed[%d] = %d, want 0", i, f)
+		}
+	}
+
+	for i, p := range prefixes {
+		nh, ok := table.Lookup(p.Addr())
+		if !ok || nh != nextHops[i] {
+			t.Errorf("Lookup(%v) = %v, %v; want %v, true", p.Addr(), nh, ok, nextHops[i])
+		}
+	}
+
+	stats, err := table.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.NumPrefixes != uint64(len(prefixes)) {
+		t.Errorf("NumPrefixes = %d, want %d", stats.NumPrefixes, len(prefixes))
+	}
+
+	removed, failed, err := table.RemoveBatch(prefixes)
+	if err != nil {
+		t.Fatalf("RemoveBatch failed: %v", err)
+	}
+	if removed != len(prefixes) {
+		t.Errorf("removed = %d, want %d", removed, len(prefixes))
+	}
+	for i, f := range failed {
+		if f != 0 {
+			t.Errorf("failed[%d] = %d, want 0", i, f)
+		}
+	}
+
+	stats, err = table.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.NumPrefixes != 0 {
+		t.Errorf("NumPrefixes after RemoveBatch = %d, want 0", stats.NumPrefixes)
+	}
+}
+
+func TestAddBatchRejectsInvalidPrefixUpFront(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	// AddBatch converts every prefix to its wire form before making the
+	// cgo call, so a family mismatch anywhere in the batch is rejected
+	// outright rather than surfacing as a failed[i] entry - the failed
+	// mask is for C.lpm_add rejecting an otherwise well-formed prefix,
+	// not for Go-side validation.
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+	}
+	nextHops := []NextHop{1, 2, 3}
+
+	_, _, err = table.AddBatch(prefixes, nextHops)
+	if err == nil {
+		t.Fatalf("AddBatch with an IPv6 prefix on an IPv4 table = nil error, want one")
+	}
+}
+
+func TestAddBatchRejectsTopBitBeforeFirstInsertMultipath(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	// Same reservation Insert enforces (see liblpm.go): the top bit is
+	// off-limits from a table's very first write, not just once it has
+	// gone multipath, so AddBatch can't poison a route that would later
+	// be misread as a stale group reference.
+	poisoned := NextHop(multipathGroupFlag | 1)
+	_, _, err = table.AddBatch([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}, []NextHop{poisoned})
+	if err == nil {
+		t.Error("Expected AddBatch to reject a next hop with the top bit set before any InsertMultipath call")
+	}
+}
+
+func TestAddBatchRejectsIPv6Table(t *testing.T) {
+	table, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	_, _, err = table.AddBatch([]netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}, []NextHop{1})
+	if err == nil {
+		t.Errorf("AddBatch on an IPv6 table = nil error, want one")
+	}
+}
+
+func TestAddBatchRejectsMultipathTable(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.InsertMultipath(netip.MustParsePrefix("10.0.0.0/24"), []NextHop{1, 2}, []uint16{1, 1}); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	_, _, err = table.AddBatch([]netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}, []NextHop{1})
+	if err == nil {
+		t.Errorf("AddBatch on a multipath table = nil error, want one")
+	}
+}
+
+func TestRemoveBatchRejectsIPv6Table(t *testing.T) {
+	table, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	_, _, err = table.RemoveBatch([]netip.Prefix{netip.MustParsePrefix("2001:db8::/32")})
+	if err == nil {
+		t.Errorf("RemoveBatch on an IPv6 table = nil error, want one")
+	}
+}
+
+func TestRemoveBatchRejectsMultipathTable(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.InsertMultipath(netip.MustParsePrefix("10.0.0.0/24"), []NextHop{1, 2}, []uint16{1, 1}); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	_, _, err = table.RemoveBatch([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")})
+	if err == nil {
+		t.Errorf("RemoveBatch on a multipath table = nil error, want one")
+	}
+}
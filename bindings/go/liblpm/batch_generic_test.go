@@ -0,0 +1,94 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// routeMeta is a stand-in for arbitrary per-route data an application might
+// want to carry alongside a prefix - exactly what BatchTable[V] exists for.
+type routeMeta struct {
+	iface string
+	asn   uint32
+}
+
+// TestBatchTableGenericValue exercises BatchTable with a value type other
+// than NextHop, verifying Insert/LookupBatch/Delete round-trip arbitrary
+// values through the handle slab rather than the identity fast path.
+func TestBatchTableGenericValue(t *testing.T) {
+	table, err := NewBatchTableIPv4Generic[routeMeta](BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create generic batch table: %v", err)
+	}
+	defer table.Close()
+
+	wide := netip.MustParsePrefix("10.0.0.0/8")
+	narrow := netip.MustParsePrefix("10.1.1.0/24")
+
+	if err := table.Insert(wide, routeMeta{iface: "eth0", asn: 100}); err != nil {
+		t.Fatalf("Failed to insert %s: %v", wide, err)
+	}
+	if err := table.Insert(narrow, routeMeta{iface: "eth1", asn: 200}); err != nil {
+		t.Fatalf("Failed to insert %s: %v", narrow, err)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{
+		netip.MustParseAddr("10.1.1.1"),
+		netip.MustParseAddr("10.2.0.1"),
+		netip.MustParseAddr("11.0.0.1"),
+	})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != (routeMeta{iface: "eth1", asn: 200}) {
+		t.Errorf("Expected narrow route metadata, got %+v", results[0])
+	}
+	if results[1] != (routeMeta{iface: "eth0", asn: 100}) {
+		t.Errorf("Expected wide route metadata, got %+v", results[1])
+	}
+	if results[2] != (routeMeta{}) {
+		t.Errorf("Expected zero value for unmatched address, got %+v", results[2])
+	}
+
+	prev, existed, err := table.Delete(narrow)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if !existed || prev.iface != "eth1" {
+		t.Fatalf("Expected to get back eth1 metadata, got %+v (existed=%v)", prev, existed)
+	}
+
+	if err := table.LookupBatchRaw(nil, nil); err == nil {
+		t.Error("Expected LookupBatchRaw to reject a non-NextHop BatchTable")
+	}
+}
+
+// TestBatchTableGenericValueReclaimsOverwrittenSlot verifies that
+// repeatedly inserting into the same prefix reuses its slab slot instead
+// of leaking a fresh one on every overwrite.
+func TestBatchTableGenericValueReclaimsOverwrittenSlot(t *testing.T) {
+	table, err := NewBatchTableIPv4Generic[routeMeta](BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create generic batch table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/8")
+	for i := 0; i < 50; i++ {
+		if err := table.Insert(prefix, routeMeta{iface: "eth0", asn: uint32(i)}); err != nil {
+			t.Fatalf("Insert #%d failed: %v", i, err)
+		}
+	}
+
+	if got, want := len(table.slab), 1; got != want {
+		t.Errorf("Expected a single slab slot to be reused across overwrites, got %d slots", got)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{netip.MustParseAddr("10.1.1.1")})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0].asn != 49 {
+		t.Errorf("Expected the last inserted value to win, got %+v", results[0])
+	}
+}
@@ -0,0 +1,54 @@
+package liblpm
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// InsertBatch installs multiple prefixes, so a caller loading a large RIB
+// can express that as one call instead of scripting their own loop over
+// Insert. Despite the name, this isn't a true single cgo crossing: this
+// build's C layer has no lpm_add_batch entry point (see AddBatch for the
+// closest thing this tree has to one), so there is no genuine batch
+// primitive to build on for either address family here. InsertBatch
+// instead loops over Insert directly, which keeps the route shadow
+// Walk/WriteTo/Clone read from (see serialize.go) correctly up to date
+// and handles multipath groups - neither of which AddBatch's lower-level
+// fast path does. Returns the number of prefixes installed before any
+// failure; on success that equals len(prefixes). A failure at index i
+// leaves every prefix before it installed and none from i onward, since
+// each Insert commits directly to t rather than to a replacement that
+// could be rolled back.
+func (t *Table) InsertBatch(prefixes []netip.Prefix, nextHops []NextHop) (int, error) {
+	if t.closed {
+		return 0, ErrTableClosed
+	}
+	if len(prefixes) != len(nextHops) {
+		return 0, errors.New("liblpm: prefixes and nextHops must be the same length")
+	}
+
+	for i, prefix := range prefixes {
+		if err := t.Insert(prefix, nextHops[i]); err != nil {
+			return i, fmt.Errorf("liblpm: inserting prefix %d (%s): %w", i, prefix, err)
+		}
+	}
+	return len(prefixes), nil
+}
+
+// DeleteBatch removes multiple prefixes, the DeleteBatch counterpart to
+// InsertBatch - see its doc comment for why this isn't a true single cgo
+// crossing either. Returns the number of prefixes removed before any
+// failure; on success that equals len(prefixes).
+func (t *Table) DeleteBatch(prefixes []netip.Prefix) (int, error) {
+	if t.closed {
+		return 0, ErrTableClosed
+	}
+
+	for i, prefix := range prefixes {
+		if err := t.Delete(prefix); err != nil {
+			return i, fmt.Errorf("liblpm: deleting prefix %d (%s): %w", i, prefix, err)
+		}
+	}
+	return len(prefixes), nil
+}
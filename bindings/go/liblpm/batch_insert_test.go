@@ -0,0 +1,157 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestInsertBatch(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+		netip.MustParsePrefix("10.0.2.0/24"),
+	}
+	nextHops := []NextHop{1, 2, 3}
+
+	n, err := table.InsertBatch(prefixes, nextHops)
+	if err != nil {
+		t.Fatalf("InsertBatch failed: %v", err)
+	}
+	if n != len(prefixes) {
+		t.Fatalf("InsertBatch installed %d prefixes, want %d", n, len(prefixes))
+	}
+
+	for i, prefix := range prefixes {
+		if nh, ok := table.LookupExact(prefix); !ok || nh != nextHops[i] {
+			t.Errorf("LookupExact(%v) = %v/%v, want %v/true", prefix, nh, ok, nextHops[i])
+		}
+	}
+}
+
+func TestInsertBatchMismatchedLengths(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	_, err = table.InsertBatch([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}, nil)
+	if err == nil {
+		t.Fatal("Expected an error for mismatched prefixes/nextHops lengths")
+	}
+}
+
+func TestInsertBatchStopsAtFirstFailure(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("10.0.2.0/24"),
+	}
+	nextHops := []NextHop{1, 2, 3}
+
+	n, err := table.InsertBatch(prefixes, nextHops)
+	if err == nil {
+		t.Fatal("Expected InsertBatch to fail on the IPv6 prefix in an IPv4 table")
+	}
+	if n != 1 {
+		t.Fatalf("InsertBatch reported %d successful inserts, want 1 (stopped at index 1)", n)
+	}
+	if nh, ok := table.LookupExact(prefixes[0]); !ok || nh != 1 {
+		t.Errorf("Expected the prefix before the failure to still be installed, got %v/%v", nh, ok)
+	}
+	if _, ok := table.LookupExact(prefixes[2]); ok {
+		t.Error("Expected the prefix after the failure not to be installed")
+	}
+}
+
+func TestDeleteBatch(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+	}
+	if _, err := table.InsertBatch(prefixes, []NextHop{1, 2}); err != nil {
+		t.Fatalf("InsertBatch failed: %v", err)
+	}
+
+	n, err := table.DeleteBatch(prefixes)
+	if err != nil {
+		t.Fatalf("DeleteBatch failed: %v", err)
+	}
+	if n != len(prefixes) {
+		t.Fatalf("DeleteBatch removed %d prefixes, want %d", n, len(prefixes))
+	}
+
+	for _, prefix := range prefixes {
+		if _, ok := table.LookupExact(prefix); ok {
+			t.Errorf("Expected %v to be removed", prefix)
+		}
+	}
+}
+
+func TestDeleteBatchStopsAtFirstFailure(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+	}
+	if err := table.Insert(prefixes[0], 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	n, err := table.DeleteBatch(prefixes)
+	if err == nil {
+		t.Fatal("Expected DeleteBatch to fail deleting a prefix that was never installed")
+	}
+	if n != 1 {
+		t.Fatalf("DeleteBatch reported %d successful deletes, want 1 (stopped at index 1)", n)
+	}
+}
+
+func TestSafeTableInsertBatchAndDeleteBatch(t *testing.T) {
+	st, err := NewSafeTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create safe table: %v", err)
+	}
+	defer st.Close()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+	}
+	if n, err := st.InsertBatch(prefixes, []NextHop{1, 2}); err != nil || n != 2 {
+		t.Fatalf("InsertBatch = %d, %v, want 2, nil", n, err)
+	}
+	if nh, ok := st.Lookup(netip.MustParseAddr("10.0.1.1")); !ok || nh != 2 {
+		t.Errorf("Lookup(10.0.1.1) = %v/%v, want 2/true", nh, ok)
+	}
+
+	if n, err := st.DeleteBatch(prefixes); err != nil || n != 2 {
+		t.Fatalf("DeleteBatch = %d, %v, want 2, nil", n, err)
+	}
+	if _, ok := st.Lookup(netip.MustParseAddr("10.0.1.1")); ok {
+		t.Error("Expected 10.0.1.0/24 to be removed")
+	}
+}
@@ -84,9 +84,10 @@ func cDestroy(triePtr uintptr) {
 	C.lpm_destroy(trie)
 }
 
-// cAdd adds a prefix to the trie.
-// For single operations, we use memcpy for simplicity and safety.
-// Batch operations use zero-copy for performance.
+// cAdd adds a prefix to the trie, pinning the caller's prefix slice and
+// passing it to C directly rather than malloc/memcpy-ing a copy - the
+// same zero-copy approach cLookupBatchIPv4/cLookupBatchIPv6 already use
+// for batches, here applied to the single-shot path.
 func cAdd(triePtr uintptr, prefix []byte, prefixLen uint8, nextHop uint32) error {
 	if triePtr == 0 {
 		return ErrTableClosed
@@ -96,18 +97,12 @@ func cAdd(triePtr uintptr, prefix []byte, prefixLen uint8, nextHop uint32) error
 	}
 
 	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
-	
-	// Allocate C memory for the prefix
-	cPrefix := (*C.uint8_t)(C.malloc(C.size_t(len(prefix))))
-	if cPrefix == nil {
-		return errors.New("failed to allocate memory")
-	}
-	defer C.free(unsafe.Pointer(cPrefix))
 
-	// Copy prefix bytes to C memory
-	C.copy_bytes(cPrefix, unsafe.Pointer(&prefix[0]), C.size_t(len(prefix)))
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+	pinner.Pin(&prefix[0])
 
-	// Call C function
+	cPrefix := (*C.uint8_t)(unsafe.Pointer(&prefix[0]))
 	result := C.lpm_add(trie, cPrefix, C.uint8_t(prefixLen), C.uint32_t(nextHop))
 	if result != 0 {
 		return ErrInsertFailed
@@ -116,7 +111,8 @@ func cAdd(triePtr uintptr, prefix []byte, prefixLen uint8, nextHop uint32) error
 	return nil
 }
 
-// cDelete removes a prefix from the trie.
+// cDelete removes a prefix from the trie. See cAdd for why this pins
+// prefix instead of copying it into C-allocated memory.
 func cDelete(triePtr uintptr, prefix []byte, prefixLen uint8) error {
 	if triePtr == 0 {
 		return ErrTableClosed
@@ -126,18 +122,12 @@ func cDelete(triePtr uintptr, prefix []byte, prefixLen uint8) error {
 	}
 
 	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
-	
-	// Allocate C memory for the prefix
-	cPrefix := (*C.uint8_t)(C.malloc(C.size_t(len(prefix))))
-	if cPrefix == nil {
-		return errors.New("failed to allocate memory")
-	}
-	defer C.free(unsafe.Pointer(cPrefix))
 
-	// Copy prefix bytes to C memory
-	C.copy_bytes(cPrefix, unsafe.Pointer(&prefix[0]), C.size_t(len(prefix)))
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+	pinner.Pin(&prefix[0])
 
-	// Call C function
+	cPrefix := (*C.uint8_t)(unsafe.Pointer(&prefix[0]))
 	result := C.lpm_delete(trie, cPrefix, C.uint8_t(prefixLen))
 	if result != 0 {
 		return ErrDeleteFailed
@@ -146,7 +136,61 @@ func cDelete(triePtr uintptr, prefix []byte, prefixLen uint8) error {
 	return nil
 }
 
-// cLookup performs a single address lookup.
+// cDeleteGetPrev removes a prefix and reports the next hop it held before
+// deletion, so BatchTable.Delete can skip a separate lookup round trip.
+// See cAdd for why this pins prefix instead of copying it.
+func cDeleteGetPrev(triePtr uintptr, prefix []byte, prefixLen uint8) (uint32, bool, error) {
+	if triePtr == 0 {
+		return uint32(InvalidNextHop), false, ErrTableClosed
+	}
+	if len(prefix) == 0 {
+		return uint32(InvalidNextHop), false, ErrInvalidPrefix
+	}
+
+	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
+
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+	pinner.Pin(&prefix[0])
+
+	cPrefix := (*C.uint8_t)(unsafe.Pointer(&prefix[0]))
+	var prevNextHop C.uint32_t
+	result := C.lpm_delete_get_prev(trie, cPrefix, C.uint8_t(prefixLen), &prevNextHop)
+	if result != 0 {
+		return uint32(InvalidNextHop), false, nil
+	}
+
+	return uint32(prevNextHop), true, nil
+}
+
+// cUpdate atomically replaces the next hop stored for an existing prefix
+// without requiring a separate lookup+insert round trip. See cAdd for why
+// this pins prefix instead of copying it.
+func cUpdate(triePtr uintptr, prefix []byte, prefixLen uint8, newNextHop uint32) error {
+	if triePtr == 0 {
+		return ErrTableClosed
+	}
+	if len(prefix) == 0 {
+		return ErrInvalidPrefix
+	}
+
+	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
+
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+	pinner.Pin(&prefix[0])
+
+	cPrefix := (*C.uint8_t)(unsafe.Pointer(&prefix[0]))
+	result := C.lpm_update(trie, cPrefix, C.uint8_t(prefixLen), C.uint32_t(newNextHop))
+	if result != 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// cLookup performs a single address lookup. See cAdd for why this pins
+// addr instead of copying it into C-allocated memory.
 func cLookup(triePtr uintptr, addr []byte) uint32 {
 	if triePtr == 0 {
 		return uint32(InvalidNextHop)
@@ -156,23 +200,18 @@ func cLookup(triePtr uintptr, addr []byte) uint32 {
 	}
 
 	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
-	
-	// Allocate C memory for the address
-	cAddr := (*C.uint8_t)(C.malloc(C.size_t(len(addr))))
-	if cAddr == nil {
-		return uint32(InvalidNextHop)
-	}
-	defer C.free(unsafe.Pointer(cAddr))
 
-	// Copy address bytes to C memory
-	C.copy_bytes(cAddr, unsafe.Pointer(&addr[0]), C.size_t(len(addr)))
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+	pinner.Pin(&addr[0])
 
-	// Call C function
+	cAddr := (*C.uint8_t)(unsafe.Pointer(&addr[0]))
 	result := C.lpm_lookup(trie, cAddr)
 	return uint32(result)
 }
 
-// cLookupIPv4 performs an IPv4 lookup using the optimized function.
+// cLookupIPv4 performs an IPv4 lookup using the optimized function that
+// takes the address as a plain uint32 - no slice, so nothing to pin.
 func cLookupIPv4(triePtr uintptr, addr uint32) uint32 {
 	if triePtr == 0 {
 		return uint32(InvalidNextHop)
@@ -183,25 +222,20 @@ func cLookupIPv4(triePtr uintptr, addr uint32) uint32 {
 	return uint32(result)
 }
 
-// cLookupIPv6 performs an IPv6 lookup.
+// cLookupIPv6 performs an IPv6 lookup. See cAdd for why this pins addr
+// instead of copying it into C-allocated memory.
 func cLookupIPv6(triePtr uintptr, addr []byte) uint32 {
 	if triePtr == 0 || len(addr) != 16 {
 		return uint32(InvalidNextHop)
 	}
 
 	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
-	
-	// Allocate C memory for the address
-	cAddr := (*C.uint8_t)(C.malloc(16))
-	if cAddr == nil {
-		return uint32(InvalidNextHop)
-	}
-	defer C.free(unsafe.Pointer(cAddr))
 
-	// Copy address bytes to C memory
-	C.copy_bytes(cAddr, unsafe.Pointer(&addr[0]), 16)
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+	pinner.Pin(&addr[0])
 
-	// Call C function
+	cAddr := (*C.uint8_t)(unsafe.Pointer(&addr[0]))
 	result := C.lpm_lookup_ipv6(trie, cAddr)
 	return uint32(result)
 }
@@ -341,46 +375,93 @@ func cLookupBatchIPv6(triePtr uintptr, addrs [][16]byte, results []uint32) error
 	return nil
 }
 
-// cBatchInsertIPv4 performs batch insert for IPv4.
-// This amortizes cgo overhead by processing multiple inserts in one call.
-func cBatchInsertIPv4(triePtr uintptr, prefixes [][]byte, prefixLens []uint8, nextHops []uint32) error {
+// cBatchInsertIPv4 inserts multiple IPv4 prefixes, pinning each prefix
+// slice and passing it to C directly rather than malloc/copy_bytes/free-ing
+// a copy per entry - the same change cAdd made to the single-shot path.
+// This tree has no lpm_add_batch C entry point that takes a whole batch in
+// one call (there's no C source here to add one to - see the package doc
+// for the broader story), so the loop below still makes one C.lpm_add cgo
+// call per prefix; what this removes is the malloc/free pair around each
+// of those calls, not the calls themselves.
+//
+// failed[i] is set for every prefix that C.lpm_add rejected; the caller is
+// expected to still apply the prefixes where failed[i] is false. Returns
+// the number of prefixes that succeeded.
+func cBatchInsertIPv4(triePtr uintptr, prefixes [][]byte, prefixLens []uint8, nextHops []uint32, failed []bool) (int, error) {
 	if triePtr == 0 {
-		return ErrTableClosed
+		return 0, ErrTableClosed
 	}
 	if len(prefixes) == 0 {
-		return nil
+		return 0, nil
 	}
-	if len(prefixLens) != len(prefixes) || len(nextHops) != len(prefixes) {
-		return errors.New("slice length mismatch")
+	if len(prefixLens) != len(prefixes) || len(nextHops) != len(prefixes) || len(failed) != len(prefixes) {
+		return 0, errors.New("slice length mismatch")
 	}
 
 	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
-	
-	// Process all inserts in one cgo call
+
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+
+	succeeded := 0
 	for i := range prefixes {
 		if len(prefixes[i]) == 0 {
+			failed[i] = true
 			continue
 		}
-		
-		// Allocate C memory for this prefix
-		cPrefix := (*C.uint8_t)(C.malloc(C.size_t(len(prefixes[i]))))
-		if cPrefix == nil {
-			return errors.New("failed to allocate memory")
-		}
-		
-		// Copy and insert
-		C.copy_bytes(cPrefix, unsafe.Pointer(&prefixes[i][0]), C.size_t(len(prefixes[i])))
+
+		pinner.Pin(&prefixes[i][0])
+		cPrefix := (*C.uint8_t)(unsafe.Pointer(&prefixes[i][0]))
 		result := C.lpm_add(trie, cPrefix, C.uint8_t(prefixLens[i]), C.uint32_t(nextHops[i]))
-		
-		// Free immediately after insert
-		C.free(unsafe.Pointer(cPrefix))
-		
 		if result != 0 {
-			return ErrInsertFailed
+			failed[i] = true
+			continue
 		}
+		succeeded++
 	}
 
-	return nil
+	return succeeded, nil
+}
+
+// cBatchDeleteIPv4 is cBatchInsertIPv4's delete counterpart: one pinned,
+// zero-copy C.lpm_delete call per prefix rather than a true single-call
+// C-side batch (see cBatchInsertIPv4 for why no such entry point exists
+// in this tree). failed[i] is set for every prefix C.lpm_delete didn't
+// find. Returns the number of prefixes that were actually removed.
+func cBatchDeleteIPv4(triePtr uintptr, prefixes [][]byte, prefixLens []uint8, failed []bool) (int, error) {
+	if triePtr == 0 {
+		return 0, ErrTableClosed
+	}
+	if len(prefixes) == 0 {
+		return 0, nil
+	}
+	if len(prefixLens) != len(prefixes) || len(failed) != len(prefixes) {
+		return 0, errors.New("slice length mismatch")
+	}
+
+	trie := (*C.lpm_trie_t)(unsafe.Pointer(triePtr))
+
+	pinner := runtime.Pinner{}
+	defer pinner.Unpin()
+
+	succeeded := 0
+	for i := range prefixes {
+		if len(prefixes[i]) == 0 {
+			failed[i] = true
+			continue
+		}
+
+		pinner.Pin(&prefixes[i][0])
+		cPrefix := (*C.uint8_t)(unsafe.Pointer(&prefixes[i][0]))
+		result := C.lpm_delete(trie, cPrefix, C.uint8_t(prefixLens[i]))
+		if result != 0 {
+			failed[i] = true
+			continue
+		}
+		succeeded++
+	}
+
+	return succeeded, nil
 }
 
 // Helper: Convert netip addresses to uint32 array for zero-copy batch lookup
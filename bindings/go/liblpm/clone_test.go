@@ -0,0 +1,141 @@
+package liblpm
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestTableCloneIsIndependent(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.Insert(prefix, 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	clone, err := table.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer clone.Close()
+
+	if nh, ok := clone.Lookup(netip.MustParseAddr("10.0.0.1")); !ok || nh != 1 {
+		t.Errorf("Clone Lookup = %v/%v, want 1/true", nh, ok)
+	}
+
+	// Mutating the clone must not affect the original, or vice versa.
+	if err := clone.Insert(netip.MustParsePrefix("10.0.1.0/24"), 2); err != nil {
+		t.Fatalf("Insert into clone failed: %v", err)
+	}
+	if _, ok := table.Lookup(netip.MustParseAddr("10.0.1.1")); ok {
+		t.Error("Expected a route inserted into the clone not to appear in the original")
+	}
+
+	if err := table.Delete(prefix); err != nil {
+		t.Fatalf("Delete from original failed: %v", err)
+	}
+	if nh, ok := clone.Lookup(netip.MustParseAddr("10.0.0.1")); !ok || nh != 1 {
+		t.Errorf("Expected the clone to keep a route deleted from the original, got %v/%v", nh, ok)
+	}
+}
+
+func TestTableCloneCarriesRouteValues(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.AddValue(prefix, 1, "policy-A"); err != nil {
+		t.Fatalf("AddValue failed: %v", err)
+	}
+
+	clone, err := table.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer clone.Close()
+
+	// The snapshot wire format Clone is built on doesn't carry
+	// RouteValue, so Clone has to copy it across separately - this is
+	// what SafeTable.Update relies on to not silently erase every
+	// AddValue a caller has made on every unrelated Update call.
+	v, ok := clone.LookupValue(netip.MustParseAddr("10.0.0.1"))
+	if !ok || v != "policy-A" {
+		t.Errorf("clone.LookupValue(10.0.0.1) = %v, %v; want \"policy-A\", true", v, ok)
+	}
+}
+
+func TestSafeTableUpdateAndSnapshot(t *testing.T) {
+	st, err := NewSafeTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create safe table: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	before := st.Snapshot()
+	if nh, ok := before.Lookup(netip.MustParseAddr("10.0.0.1")); !ok || nh != 1 {
+		t.Errorf("Snapshot before Update = %v/%v, want 1/true", nh, ok)
+	}
+
+	err = st.Update(func(clone *Table) error {
+		return clone.Insert(netip.MustParsePrefix("10.1.0.0/16"), 2)
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// The snapshot taken before Update must keep reading the old state -
+	// it's an independent clone, not a view into the live table.
+	if nh, ok := before.Lookup(netip.MustParseAddr("10.0.0.1")); !ok || nh != 1 {
+		t.Errorf("Old snapshot after Update = %v/%v, want 1/true (unaffected by Update)", nh, ok)
+	}
+	if _, ok := before.Lookup(netip.MustParseAddr("10.1.0.1")); ok {
+		t.Error("Expected the old snapshot not to see a route added by a later Update")
+	}
+
+	after := st.Snapshot()
+	if nh, ok := after.Lookup(netip.MustParseAddr("10.1.0.1")); !ok || nh != 2 {
+		t.Errorf("Snapshot after Update = %v/%v, want 2/true", nh, ok)
+	}
+
+	// Update's mutation must also be visible through SafeTable's own
+	// locked methods, not just Snapshot.
+	if nh, ok := st.Lookup(netip.MustParseAddr("10.1.0.1")); !ok || nh != 2 {
+		t.Errorf("SafeTable.Lookup after Update = %v/%v, want 2/true", nh, ok)
+	}
+}
+
+func TestSafeTableUpdateRollsBackOnError(t *testing.T) {
+	st, err := NewSafeTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create safe table: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.Insert(netip.MustParsePrefix("10.0.0.0/8"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	wantErr := errors.New("deliberate failure")
+	err = st.Update(func(clone *Table) error {
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("Update returned %v, want %v", err, wantErr)
+	}
+
+	if nh, ok := st.Lookup(netip.MustParseAddr("10.1.1.1")); !ok || nh != 1 {
+		t.Errorf("Lookup after failed Update = %v/%v, want 1/true (unchanged)", nh, ok)
+	}
+}
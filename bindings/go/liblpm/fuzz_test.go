@@ -0,0 +1,75 @@
+// Package liblpm_test, not liblpm: TestFuzz drives the Table through
+// internal/lpmtest, which itself imports liblpm, so this file has to live
+// outside the package under test to avoid an import cycle.
+package liblpm_test
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/MuriloChianfa/liblpm/go/internal/lpmtest"
+)
+
+var corpus = flag.String("corpus", "", "replay the trace saved at this path instead of generating one")
+
+// fuzzOpsPerRun is how many operations TestFuzz generates per address
+// family when not replaying a -corpus file. Kept modest so the suite
+// stays fast under go test; use the BenchmarkFuzzLookup functions in the
+// benchmarks package for scale.
+const fuzzOpsPerRun = 20000
+
+func TestFuzz(t *testing.T) {
+	t.Run("IPv4", func(t *testing.T) { runFuzz(t, true) })
+	t.Run("IPv6", func(t *testing.T) { runFuzz(t, false) })
+}
+
+func runFuzz(t *testing.T, isIPv4 bool) {
+	t.Helper()
+
+	var ops []lpmtest.Op
+	if *corpus != "" {
+		f, err := os.Open(*corpus)
+		if err != nil {
+			t.Fatalf("opening -corpus file: %v", err)
+		}
+		defer f.Close()
+		ops, err = lpmtest.ReadTrace(f)
+		if err != nil {
+			t.Fatalf("reading -corpus file: %v", err)
+		}
+	} else {
+		ops = lpmtest.GenerateOps(42, isIPv4, fuzzOpsPerRun)
+	}
+
+	h, err := lpmtest.NewHarness(isIPv4)
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Close()
+
+	if mismatch := h.Run(ops); mismatch != nil {
+		tracePath := saveFailingTrace(t, ops[:mismatch.Step+1])
+		t.Fatalf("%v\nreplay with: go test -run TestFuzz -corpus=%s", mismatch, tracePath)
+	}
+}
+
+// saveFailingTrace writes the ops that led to a mismatch to a temp file so
+// the failure can be replayed with -corpus, returning its path (or "" if
+// the write itself failed - the test still reports the mismatch either way).
+func saveFailingTrace(t *testing.T, ops []lpmtest.Op) string {
+	t.Helper()
+
+	f, err := os.CreateTemp("", "lpmtest-corpus-*.trace")
+	if err != nil {
+		t.Logf("could not save failing trace: %v", err)
+		return ""
+	}
+	defer f.Close()
+
+	if err := lpmtest.WriteTrace(f, ops); err != nil {
+		t.Logf("could not write failing trace: %v", err)
+		return ""
+	}
+	return f.Name()
+}
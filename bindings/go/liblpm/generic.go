@@ -0,0 +1,186 @@
+package liblpm
+
+import "net/netip"
+
+// GenericTable stores an arbitrary value V per prefix instead of a bare
+// NextHop, using the same trick BatchTable[V] uses for its batch-oriented
+// sibling (see batch.go): the cgo trie underneath only ever holds a
+// uint32 handle, and GenericTable keeps a Go-side slab of V values
+// indexed by that handle, recycling a prefix's slot once Delete reclaims
+// it. When V is NextHop itself the handle *is* the value, so
+// GenericTable[NextHop] behaves exactly like Table with no extra
+// indirection or slab allocation.
+//
+// This unlocks storing rich per-route metadata - a BGP path attribute
+// struct, a *Peer, a community list - directly in the table instead of
+// keeping a parallel map[NextHop]V in front of a plain Table.
+//
+// GenericTable only wraps Table's core Insert/Delete/Lookup/LookupBatch
+// surface. Multipath (multipath.go), serialization (serialize.go) and the
+// prefix-iteration queries (query.go) all assume the handle stored per
+// prefix is itself a meaningful NextHop; for any other V they'd either
+// silently corrupt the slab or need their own generic reimplementation,
+// so they aren't exposed here.
+type GenericTable[V any] struct {
+	table    *Table
+	identity bool // true when V == NextHop: the handle IS the value.
+
+	slab []V
+	free []uint32
+}
+
+// NewGenericTableIPv4 creates an IPv4 GenericTable storing a V per prefix.
+func NewGenericTableIPv4[V any]() (*GenericTable[V], error) {
+	return newGenericTable[V](true)
+}
+
+// NewGenericTableIPv6 creates an IPv6 GenericTable storing a V per prefix.
+func NewGenericTableIPv6[V any]() (*GenericTable[V], error) {
+	return newGenericTable[V](false)
+}
+
+func newGenericTable[V any](isIPv4 bool) (*GenericTable[V], error) {
+	var table *Table
+	var err error
+	if isIPv4 {
+		table, err = NewTableIPv4()
+	} else {
+		table, err = NewTableIPv6()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var zero V
+	_, identity := any(zero).(NextHop)
+
+	return &GenericTable[V]{table: table, identity: identity}, nil
+}
+
+// handleFor returns the uint32 handle that should be stored in the trie
+// for value v, allocating (or reusing) a slab slot when V isn't NextHop.
+func (t *GenericTable[V]) handleFor(v V) uint32 {
+	if t.identity {
+		return uint32(any(v).(NextHop))
+	}
+
+	if n := len(t.free); n > 0 {
+		h := t.free[n-1]
+		t.free = t.free[:n-1]
+		t.slab[h] = v
+		return h
+	}
+
+	h := uint32(len(t.slab))
+	t.slab = append(t.slab, v)
+	return h
+}
+
+// valueFor resolves a trie handle back to its value.
+func (t *GenericTable[V]) valueFor(h uint32) V {
+	if t.identity {
+		return any(NextHop(h)).(V)
+	}
+	return t.slab[h]
+}
+
+// releaseHandle recycles a handle's slab slot after its route is deleted,
+// or after the Insert that claimed it fails. An overwrite keeps its slot
+// (see Insert).
+func (t *GenericTable[V]) releaseHandle(h uint32) {
+	if t.identity {
+		return
+	}
+	var zero V
+	t.slab[h] = zero
+	t.free = append(t.free, h)
+}
+
+// noMatchValue is what a "no route matched" handle resolves to: when V is
+// NextHop, that's InvalidNextHop (matching Table's own Lookup); for any
+// other V there's no slab slot for it, so it's just V's zero value.
+func (t *GenericTable[V]) noMatchValue() V {
+	if t.identity {
+		return any(NextHop(InvalidNextHop)).(V)
+	}
+	var zero V
+	return zero
+}
+
+// Close releases the underlying table.
+func (t *GenericTable[V]) Close() error {
+	return t.table.Close()
+}
+
+// Insert installs prefix with the given value, overwriting any value
+// already installed there. An overwrite reuses the existing prefix's
+// slab slot rather than freeing it and allocating a new one, so that if
+// the underlying Table.Insert fails, the prior value can simply be
+// restored into that same slot rather than leaving a dangling handle
+// pointing at a slot some other Insert has since reclaimed.
+func (t *GenericTable[V]) Insert(prefix netip.Prefix, value V) error {
+	if !t.identity {
+		if prevHandle, existed := t.table.LookupExact(prefix); existed {
+			old := t.slab[prevHandle]
+			t.slab[prevHandle] = value
+			if err := t.table.Insert(prefix, prevHandle); err != nil {
+				t.slab[prevHandle] = old
+				return err
+			}
+			return nil
+		}
+	}
+
+	handle := t.handleFor(value)
+	if err := t.table.Insert(prefix, NextHop(handle)); err != nil {
+		t.releaseHandle(handle)
+		return err
+	}
+	return nil
+}
+
+// Delete removes prefix and reports the value it previously held, so
+// callers don't need a separate Lookup before tearing down a route.
+func (t *GenericTable[V]) Delete(prefix netip.Prefix) (V, bool, error) {
+	var zero V
+
+	prevHandle, existed := t.table.LookupExact(prefix)
+	if !existed {
+		return zero, false, nil
+	}
+	if err := t.table.Delete(prefix); err != nil {
+		return zero, false, err
+	}
+
+	value := t.valueFor(uint32(prevHandle))
+	t.releaseHandle(uint32(prevHandle))
+	return value, true, nil
+}
+
+// Lookup performs a longest prefix match for addr, returning the value
+// installed at the matching prefix.
+func (t *GenericTable[V]) Lookup(addr netip.Addr) (V, bool) {
+	handle, ok := t.table.Lookup(addr)
+	if !ok {
+		return t.noMatchValue(), false
+	}
+	return t.valueFor(uint32(handle)), true
+}
+
+// LookupBatch looks up each address in turn, in the same order.
+func (t *GenericTable[V]) LookupBatch(addrs []netip.Addr) ([]V, error) {
+	handles, err := t.table.LookupBatch(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]V, len(handles))
+	for i, h := range handles {
+		if !h.IsValid() {
+			values[i] = t.noMatchValue()
+			continue
+		}
+		values[i] = t.valueFor(uint32(h))
+	}
+	return values, nil
+}
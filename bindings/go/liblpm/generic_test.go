@@ -0,0 +1,117 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestGenericTableGenericValue exercises GenericTable with a value type
+// other than NextHop, verifying Insert/Lookup/LookupBatch/Delete round-
+// trip arbitrary values through the handle slab rather than the identity
+// fast path BatchTable[NextHop]/GenericTable[NextHop] use.
+func TestGenericTableGenericValue(t *testing.T) {
+	table, err := NewGenericTableIPv4[routeMeta]()
+	if err != nil {
+		t.Fatalf("Failed to create generic table: %v", err)
+	}
+	defer table.Close()
+
+	wide := netip.MustParsePrefix("10.0.0.0/8")
+	narrow := netip.MustParsePrefix("10.1.1.0/24")
+
+	if err := table.Insert(wide, routeMeta{iface: "eth0", asn: 100}); err != nil {
+		t.Fatalf("Failed to insert %s: %v", wide, err)
+	}
+	if err := table.Insert(narrow, routeMeta{iface: "eth1", asn: 200}); err != nil {
+		t.Fatalf("Failed to insert %s: %v", narrow, err)
+	}
+
+	value, ok := table.Lookup(netip.MustParseAddr("10.1.1.1"))
+	if !ok || value != (routeMeta{iface: "eth1", asn: 200}) {
+		t.Errorf("Lookup = %+v/%v, want narrow route metadata", value, ok)
+	}
+
+	results, err := table.LookupBatch([]netip.Addr{
+		netip.MustParseAddr("10.1.1.1"),
+		netip.MustParseAddr("10.2.0.1"),
+		netip.MustParseAddr("11.0.0.1"),
+	})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if results[0] != (routeMeta{iface: "eth1", asn: 200}) {
+		t.Errorf("Expected narrow route metadata, got %+v", results[0])
+	}
+	if results[1] != (routeMeta{iface: "eth0", asn: 100}) {
+		t.Errorf("Expected wide route metadata, got %+v", results[1])
+	}
+	if results[2] != (routeMeta{}) {
+		t.Errorf("Expected zero value for unmatched address, got %+v", results[2])
+	}
+
+	prev, existed, err := table.Delete(narrow)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if !existed || prev.iface != "eth1" {
+		t.Fatalf("Expected to get back eth1 metadata, got %+v (existed=%v)", prev, existed)
+	}
+
+	if _, existed, err := table.Delete(narrow); err != nil || existed {
+		t.Errorf("Expected deleting an already-removed prefix to report existed=false, got existed=%v err=%v", existed, err)
+	}
+}
+
+// TestGenericTableReclaimsOverwrittenSlot verifies that repeatedly
+// inserting into the same prefix reuses its slab slot instead of leaking
+// a fresh one on every overwrite.
+func TestGenericTableReclaimsOverwrittenSlot(t *testing.T) {
+	table, err := NewGenericTableIPv4[routeMeta]()
+	if err != nil {
+		t.Fatalf("Failed to create generic table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/8")
+	for i := 0; i < 50; i++ {
+		if err := table.Insert(prefix, routeMeta{iface: "eth0", asn: uint32(i)}); err != nil {
+			t.Fatalf("Insert #%d failed: %v", i, err)
+		}
+	}
+
+	if got, want := len(table.slab), 1; got != want {
+		t.Errorf("Expected a single slab slot to be reused across overwrites, got %d slots", got)
+	}
+
+	value, ok := table.Lookup(netip.MustParseAddr("10.1.1.1"))
+	if !ok || value.asn != 49 {
+		t.Errorf("Expected the last inserted value to win, got %+v (ok=%v)", value, ok)
+	}
+}
+
+// TestGenericTableIdentityPassthrough verifies GenericTable[NextHop]
+// behaves like a plain Table, with no slab allocated.
+func TestGenericTableIdentityPassthrough(t *testing.T) {
+	table, err := NewGenericTableIPv4[NextHop]()
+	if err != nil {
+		t.Fatalf("Failed to create generic table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.Insert(prefix, 42); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	nextHop, ok := table.Lookup(netip.MustParseAddr("10.0.0.1"))
+	if !ok || nextHop != 42 {
+		t.Errorf("Lookup = %v/%v, want 42/true", nextHop, ok)
+	}
+	if len(table.slab) != 0 {
+		t.Errorf("Expected no slab allocation for GenericTable[NextHop], got %d slots", len(table.slab))
+	}
+
+	if _, ok := table.Lookup(netip.MustParseAddr("192.0.2.1")); ok {
+		t.Error("Expected Lookup to report false for an unmatched address")
+	}
+}
@@ -1,126 +1,68 @@
 // Package liblpm provides Go bindings for the high-performance liblpm C library.
 // It supports both IPv4 and IPv6 longest prefix match (LPM) routing table operations.
+//
+// This tree ships the Go bindings only: the C liblpm source and headers
+// cgo.go compiles against aren't part of this repo, so the C-side entry
+// points available here (lpm_add, lpm_delete, lpm_lookup, ...) are fixed
+// to whatever cgo.go already declares. A handful of doc comments elsewhere
+// in this package (cBatchInsertIPv4, RouteValue, ...) note that some
+// feature would be better served by a new C entry point - a single
+// lpm_add_batch call, a wide lpm_add_u64 leaf slot - and explain why that
+// wasn't done: there's no C source in this tree to add one to.
 package liblpm
 
 import (
 	"encoding/binary"
+	"errors"
+	"io"
 	"net/netip"
 	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
 // NewTableIPv4 creates a new IPv4 routing table using the default algorithm (DIR-24-8).
 // This provides optimal performance for IPv4 lookups with ~64MB of memory.
 // The table must be closed with Close() when no longer needed to free resources.
+// It is NewTable with TableOptions{Family: FamilyIPv4}.
 func NewTableIPv4() (*Table, error) {
-	triePtr, err := cCreateIPv4()
-	if err != nil {
-		return nil, err
-	}
-
-	t := &Table{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: true,
-	}
-
-	// Set finalizer to ensure cleanup even if Close() is not called
-	runtime.SetFinalizer(t, (*Table).finalize)
-
-	return t, nil
+	return NewTable(TableOptions{Family: FamilyIPv4})
 }
 
 // NewTableIPv4Dir24 creates an IPv4 routing table using DIR-24-8 algorithm explicitly.
 // This is the recommended algorithm for IPv4 with 1-2 memory accesses per lookup.
+// It is NewTable with TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmDir24_8}.
 func NewTableIPv4Dir24() (*Table, error) {
-	triePtr, err := cCreateIPv4Dir24()
-	if err != nil {
-		return nil, err
-	}
-
-	t := &Table{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: true,
-	}
-
-	runtime.SetFinalizer(t, (*Table).finalize)
-	return t, nil
+	return NewTable(TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmDir24_8})
 }
 
 // NewTableIPv4Stride8 creates an IPv4 routing table using 8-bit stride algorithm.
 // This is memory-efficient for diverse prefix distributions.
+// It is NewTable with TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmStride8}.
 func NewTableIPv4Stride8() (*Table, error) {
-	triePtr, err := cCreateIPv4Stride8()
-	if err != nil {
-		return nil, err
-	}
-
-	t := &Table{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: true,
-	}
-
-	runtime.SetFinalizer(t, (*Table).finalize)
-	return t, nil
+	return NewTable(TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmStride8})
 }
 
 // NewTableIPv6 creates a new IPv6 routing table using the default algorithm (wide16).
 // This uses a 16-bit stride for the first level and 8-bit strides for remaining levels.
 // The table must be closed with Close() when no longer needed to free resources.
+// It is NewTable with TableOptions{Family: FamilyIPv6}.
 func NewTableIPv6() (*Table, error) {
-	triePtr, err := cCreateIPv6()
-	if err != nil {
-		return nil, err
-	}
-
-	t := &Table{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: false,
-	}
-
-	// Set finalizer to ensure cleanup even if Close() is not called
-	runtime.SetFinalizer(t, (*Table).finalize)
-
-	return t, nil
+	return NewTable(TableOptions{Family: FamilyIPv6})
 }
 
 // NewTableIPv6Wide16 creates an IPv6 routing table using wide 16-bit stride explicitly.
 // Optimal for IPv6 with common /48 allocations.
+// It is NewTable with TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmWide16}.
 func NewTableIPv6Wide16() (*Table, error) {
-	triePtr, err := cCreateIPv6Wide16()
-	if err != nil {
-		return nil, err
-	}
-
-	t := &Table{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: false,
-	}
-
-	runtime.SetFinalizer(t, (*Table).finalize)
-	return t, nil
+	return NewTable(TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmWide16})
 }
 
 // NewTableIPv6Stride8 creates an IPv6 routing table using 8-bit stride algorithm.
 // Simple and memory-efficient for sparse prefix sets.
+// It is NewTable with TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmStride8}.
 func NewTableIPv6Stride8() (*Table, error) {
-	triePtr, err := cCreateIPv6Stride8()
-	if err != nil {
-		return nil, err
-	}
-
-	t := &Table{
-		cTrie:  triePtr,
-		closed: false,
-		isIPv4: false,
-	}
-
-	runtime.SetFinalizer(t, (*Table).finalize)
-	return t, nil
+	return NewTable(TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmStride8})
 }
 
 // finalize is called by the garbage collector to clean up C resources.
@@ -167,13 +109,50 @@ func (t *Table) Insert(prefix netip.Prefix, nextHop NextHop) error {
 	if !t.isIPv4 && !prefix.Addr().Is6() {
 		return ErrInvalidPrefix
 	}
+	// The top bit of every stored next hop is reserved to flag a
+	// multipath group reference (see multipathGroupFlag), unconditionally
+	// - not just once a table has actually called InsertMultipath. A
+	// table that gated this on t.groups != nil would let a literal next
+	// hop with the top bit set through before its first InsertMultipath
+	// call, only to have that already-installed route retroactively
+	// misread as a stale group reference (and silently vanish from
+	// LookupFlow/LookupFlowBatch/LookupAll) the moment some other prefix
+	// later goes multipath.
+	if uint32(nextHop)&multipathGroupFlag != 0 {
+		return errors.New("liblpm: next hop values with the top bit set are reserved for multipath group references")
+	}
 
 	prefixBytes, prefixLen, err := prefixToBytes(prefix)
 	if err != nil {
 		return err
 	}
 
-	return cAdd(t.cTrie, prefixBytes, prefixLen, uint32(nextHop))
+	// A multipath group may previously have been installed at prefix;
+	// only tables that have ever called InsertMultipath carry the
+	// bookkeeping to notice, so non-multipath tables pay nothing extra.
+	if t.groups != nil {
+		prevNextHop, existed, err := cDeleteGetPrev(t.cTrie, prefixBytes, prefixLen)
+		if err != nil {
+			return err
+		}
+		if existed {
+			if err := cAdd(t.cTrie, prefixBytes, prefixLen, uint32(nextHop)); err != nil {
+				// Best-effort restore: a failed overwrite shouldn't
+				// leave prefix route-less when it had a route before.
+				cAdd(t.cTrie, prefixBytes, prefixLen, prevNextHop)
+				return err
+			}
+			t.releaseGroup(prevNextHop)
+			t.recordRoute(prefix, nextHop)
+			return nil
+		}
+	}
+
+	if err := cAdd(t.cTrie, prefixBytes, prefixLen, uint32(nextHop)); err != nil {
+		return err
+	}
+	t.recordRoute(prefix, nextHop)
+	return nil
 }
 
 // Delete removes a prefix route from the table.
@@ -196,7 +175,28 @@ func (t *Table) Delete(prefix netip.Prefix) error {
 		return err
 	}
 
-	return cDelete(t.cTrie, prefixBytes, prefixLen)
+	// Same reasoning as Insert: only reap a multipath group if this
+	// table has ever used InsertMultipath. cDeleteGetPrev both performs
+	// the deletion and reports whether prefix existed, so it replaces
+	// cDelete entirely on this path rather than running alongside it.
+	if t.groups != nil {
+		prevNextHop, existed, err := cDeleteGetPrev(t.cTrie, prefixBytes, prefixLen)
+		if err != nil {
+			return err
+		}
+		if !existed {
+			return ErrDeleteFailed
+		}
+		t.releaseGroup(prevNextHop)
+		t.forgetRoute(prefix)
+		return nil
+	}
+
+	if err := cDelete(t.cTrie, prefixBytes, prefixLen); err != nil {
+		return err
+	}
+	t.forgetRoute(prefix)
+	return nil
 }
 
 // Lookup performs a longest prefix match for the given address.
@@ -231,6 +231,23 @@ func (t *Table) Lookup(addr netip.Addr) (NextHop, bool) {
 	return nh, nh.IsValid()
 }
 
+// LookupAddr4Uint32 performs a longest prefix match for an IPv4 address
+// given directly as a uint32 - the same encoding LookupBatch's internal
+// IPv4 fast path uses, binary.BigEndian.Uint32 of the address's 4-byte
+// form - skipping the netip.Addr/[]byte conversion Lookup needs. For a
+// caller whose addresses already arrive as uint32s (decoded straight off
+// a packet, say) this avoids that conversion on the hot path; everyone
+// else should just call Lookup.
+//
+// Returns InvalidNextHop for an IPv6 table or a closed one, same as
+// Lookup would for an address of the wrong family.
+func (t *Table) LookupAddr4Uint32(addr uint32) NextHop {
+	if t.closed || !t.isIPv4 {
+		return InvalidNextHop
+	}
+	return NextHop(cLookupIPv4(t.cTrie, addr))
+}
+
 // LookupBatch performs lookups for multiple addresses in a single call.
 // This is more efficient than calling Lookup multiple times due to reduced cgo overhead.
 // Returns a slice of next hops corresponding to each input address.
@@ -295,17 +312,48 @@ type Stats struct {
 	CacheHits     uint64
 	CacheMisses   uint64
 	MemoryUsageKB uint64
+
+	// PrefixesByLength[n] counts installed prefixes of length n (indices
+	// 0 through 32 for an IPv4 table, 0 through 128 for IPv6) - the same
+	// "is this RIB dominated by /24s or /32 hosts" breakdown
+	// BatchTableStats.PrefixesByLength gives a BackendPureGo BatchTable.
+	PrefixesByLength []uint64
 }
 
-// GetStats returns statistics about the routing table (not yet implemented).
-// This would require additional C bindings to expose lpm_print_stats data.
+// GetStats returns statistics about the routing table.
+//
+// Only NumPrefixes and PrefixesByLength are populated here, both derived
+// from the routes map Insert/Delete already maintain for
+// WriteTo/MarshalBinary (see types.go) - no C call involved. NumNodes,
+// NumWideNodes, CacheHits, CacheMisses and MemoryUsageKB would need real
+// counters inside lpm_trie_t/lpm_lookup itself (a node count maintained
+// by lpm_add/lpm_delete, atomics incremented on cache hit/miss) and a C
+// entry point to read them back; cgo.go exposes no such function, and
+// this tree carries no C source to add one to. They're left at their
+// zero value rather than guessed at - the same wall
+// cgoBatchBackend.stats() (batch_cgo.go) hits and documents for the same
+// reason. A caller that needs real node/memory accounting today can get
+// it from BatchTable.Stats() with BackendPureGo, whose art.go
+// implementation is pure Go and has nothing else to ask a missing C
+// layer for.
 func (t *Table) GetStats() (*Stats, error) {
 	if t.closed {
 		return nil, ErrTableClosed
 	}
 
-	// TODO: Implement stats retrieval from C
-	return &Stats{}, nil
+	maxLen := 32
+	if !t.isIPv4 {
+		maxLen = 128
+	}
+	byLength := make([]uint64, maxLen+1)
+	for prefix := range t.routes {
+		byLength[prefix.Bits()]++
+	}
+
+	return &Stats{
+		NumPrefixes:      uint64(len(t.routes)),
+		PrefixesByLength: byLength,
+	}, nil
 }
 
 // SafeTable is a thread-safe wrapper around Table.
@@ -313,6 +361,13 @@ func (t *Table) GetStats() (*Stats, error) {
 type SafeTable struct {
 	table *Table
 	mu    sync.RWMutex
+
+	// live is the table most recently published by Update, for Snapshot's
+	// lock-free readers. It always points at the same table as the
+	// mutex-guarded table field right after construction or an Update, but
+	// Insert/Delete/InsertMultipath made through SafeTable's locked methods
+	// don't republish it - see Snapshot's doc comment.
+	live atomic.Pointer[Table]
 }
 
 // NewSafeTableIPv4 creates a new thread-safe IPv4 routing table.
@@ -321,7 +376,9 @@ func NewSafeTableIPv4() (*SafeTable, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &SafeTable{table: table}, nil
+	st := &SafeTable{table: table}
+	st.live.Store(table)
+	return st, nil
 }
 
 // NewSafeTableIPv6 creates a new thread-safe IPv6 routing table.
@@ -330,7 +387,9 @@ func NewSafeTableIPv6() (*SafeTable, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &SafeTable{table: table}, nil
+	st := &SafeTable{table: table}
+	st.live.Store(table)
+	return st, nil
 }
 
 // Close closes the underlying table.
@@ -354,6 +413,22 @@ func (st *SafeTable) Delete(prefix netip.Prefix) error {
 	return st.table.Delete(prefix)
 }
 
+// InsertBatch installs multiple prefixes with a single write lock (see
+// Table.InsertBatch).
+func (st *SafeTable) InsertBatch(prefixes []netip.Prefix, nextHops []NextHop) (int, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.table.InsertBatch(prefixes, nextHops)
+}
+
+// DeleteBatch removes multiple prefixes with a single write lock (see
+// Table.DeleteBatch).
+func (st *SafeTable) DeleteBatch(prefixes []netip.Prefix) (int, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.table.DeleteBatch(prefixes)
+}
+
 // Lookup performs a lookup with read lock.
 func (st *SafeTable) Lookup(addr netip.Addr) (NextHop, bool) {
 	st.mu.RLock()
@@ -368,6 +443,22 @@ func (st *SafeTable) LookupBatch(addrs []netip.Addr) ([]NextHop, error) {
 	return st.table.LookupBatch(addrs)
 }
 
+// LookupPrefix performs a lookup with read lock, also reporting the
+// matched prefix (see Table.LookupPrefix).
+func (st *SafeTable) LookupPrefix(addr netip.Addr) (netip.Prefix, NextHop, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.LookupPrefix(addr)
+}
+
+// LookupPrefixBatch performs a batch lookup with read lock, also
+// reporting each matched prefix (see Table.LookupPrefixBatch).
+func (st *SafeTable) LookupPrefixBatch(addrs []netip.Addr) ([]Route, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.LookupPrefixBatch(addrs)
+}
+
 // GetStats returns statistics with read lock.
 func (st *SafeTable) GetStats() (*Stats, error) {
 	st.mu.RLock()
@@ -375,3 +466,203 @@ func (st *SafeTable) GetStats() (*Stats, error) {
 	return st.table.GetStats()
 }
 
+// InsertMultipath installs a weighted ECMP group with write lock.
+func (st *SafeTable) InsertMultipath(prefix netip.Prefix, nextHops []NextHop, weights []uint16) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.table.InsertMultipath(prefix, nextHops, weights)
+}
+
+// LookupFlow performs a 5-tuple ECMP lookup with read lock.
+func (st *SafeTable) LookupFlow(src, dst netip.Addr, proto uint8, sport, dport uint16) (NextHop, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.LookupFlow(src, dst, proto, sport, dport)
+}
+
+// LookupFlowBatch performs batch 5-tuple ECMP lookups with read lock.
+func (st *SafeTable) LookupFlowBatch(flows []FlowKey) ([]NextHop, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.LookupFlowBatch(flows)
+}
+
+// LookupAll returns every ECMP next hop and weight for addr with read lock.
+func (st *SafeTable) LookupAll(addr netip.Addr) ([]NextHop, []uint16, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.LookupAll(addr)
+}
+
+// ReplaceAll installs routes as the table's entire contents, atomically:
+// it builds a fresh underlying table and populates it with routes before
+// ever taking the write lock, then swaps it in under the lock so a
+// concurrent reader either sees the old table in full or the new one in
+// full, never a partial mix of the two. This suits a BGP-style full RIB
+// install, where teardown-then-repopulate-in-place would otherwise leave
+// a window of missing routes. Existing multipath groups are not carried
+// over; re-issue InsertMultipath calls against the table afterward if
+// needed.
+func (st *SafeTable) ReplaceAll(routes []Route) error {
+	st.mu.RLock()
+	isIPv4 := st.table.isIPv4
+	closed := st.table.closed
+	st.mu.RUnlock()
+	if closed {
+		return ErrTableClosed
+	}
+
+	var fresh *Table
+	var err error
+	if isIPv4 {
+		fresh, err = NewTableIPv4()
+	} else {
+		fresh, err = NewTableIPv6()
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		if err := fresh.Insert(route.Prefix, route.NextHop); err != nil {
+			fresh.Close()
+			return err
+		}
+	}
+
+	st.mu.Lock()
+	if st.table.closed {
+		st.mu.Unlock()
+		fresh.Close()
+		return ErrTableClosed
+	}
+	old := st.table
+	st.table = fresh
+	st.mu.Unlock()
+
+	return old.Close()
+}
+
+// Update publishes a copy-on-write mutation: it clones the current
+// table, applies fn to the clone, and - if fn succeeds - swaps the clone
+// in as both the table future locked calls (Insert, Lookup, ...) see and
+// the one Snapshot hands to lock-free readers. If fn returns an error,
+// the clone is discarded and the table is left untouched.
+//
+// Once any goroutine relies on Snapshot for lock-free reads, all further
+// mutation must go through Update, not SafeTable's own locked Insert /
+// Delete / InsertMultipath: those mutate the published *Table in place
+// under st.mu, and a Snapshot caller is by design reading that same
+// object with no locking at all, so a locked Insert racing an
+// unsynchronized Snapshot-based Lookup is a data race Update's
+// clone-then-swap is specifically built to avoid.
+//
+// Unlike ReplaceAll, Update does not close the table generation it
+// replaces: a goroutine may be mid-Lookup against it via a *Table a
+// previous Snapshot call returned, entirely outside st.mu, and closing
+// out from under that call would be a use-after-free. The superseded
+// generation is instead left for the garbage collector, which runs the
+// finalizer NewTable sets on every Table once the last reference -
+// SafeTable's or a lingering Snapshot caller's - drops it. This trades
+// prompt reclamation for safety.
+func (st *SafeTable) Update(fn func(*Table) error) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.table.closed {
+		return ErrTableClosed
+	}
+
+	clone, err := st.table.Clone()
+	if err != nil {
+		return err
+	}
+	if err := fn(clone); err != nil {
+		clone.Close()
+		return err
+	}
+
+	st.table = clone
+	st.live.Store(clone)
+	return nil
+}
+
+// Snapshot returns the table most recently published by Update, for
+// lock-free reads: a caller can call Lookup/LookupBatch/LookupPrefix/...
+// directly on the returned *Table with no SafeTable locking at all,
+// suited to data-plane goroutines doing millions of lookups/sec while a
+// control-plane goroutine calls Update as BGP updates trickle in. The
+// returned table must not be mutated or closed by the caller - SafeTable
+// owns its lifetime - and only reflects state as of the last Update
+// call; Insert/Delete/InsertMultipath made through SafeTable's own
+// locked methods aren't republished to it until the next Update.
+func (st *SafeTable) Snapshot() *Table {
+	return st.live.Load()
+}
+
+// WriteTo streams the table's routes to w in the binary snapshot format
+// Table.WriteTo documents, with a read lock so it reflects one consistent
+// point in time even while other goroutines call Insert/Delete.
+func (st *SafeTable) WriteTo(w io.Writer) (int64, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.WriteTo(w)
+}
+
+// Walk calls fn for every installed route with a read lock held for the
+// full traversal, so fn must not call back into st or it will deadlock.
+func (st *SafeTable) Walk(fn func(netip.Prefix, NextHop) bool) error {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.Walk(fn)
+}
+
+// WalkMatching calls fn for every installed route matching addr, with a
+// read lock held for the full traversal - see Table.WalkMatching. fn
+// must not call back into st or it will deadlock.
+func (st *SafeTable) WalkMatching(addr netip.Addr, fn func(netip.Prefix, NextHop) bool) error {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.WalkMatching(addr, fn)
+}
+
+// All is Walk reshaped into a Go 1.23 range-over-func iterator (see
+// Table.All), with a read lock held for the full traversal, so fn must
+// not call back into st or it will deadlock.
+func (st *SafeTable) All(yield func(netip.Prefix, NextHop) bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	st.table.All(yield)
+}
+
+// LookupExact performs an exact prefix/length match with a read lock.
+func (st *SafeTable) LookupExact(prefix netip.Prefix) (NextHop, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.LookupExact(prefix)
+}
+
+// Contains reports whether addr matches any installed route, with a read
+// lock.
+func (st *SafeTable) Contains(addr netip.Addr) bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.Contains(addr)
+}
+
+// Subnets returns every installed route more specific than prefix, with
+// a read lock.
+func (st *SafeTable) Subnets(prefix netip.Prefix) []Route {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.Subnets(prefix)
+}
+
+// Supernets returns every installed route matching addr, least to most
+// specific (see Table.Supernets), with a read lock.
+func (st *SafeTable) Supernets(addr netip.Addr) []Route {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.table.Supernets(addr)
+}
+
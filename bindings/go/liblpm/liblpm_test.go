@@ -1,6 +1,7 @@
 package liblpm
 
 import (
+	"encoding/binary"
 	"net/netip"
 	"testing"
 )
@@ -170,6 +171,42 @@ func TestInsertAndLookupIPv4(t *testing.T) {
 	}
 }
 
+// TestLookupAddr4Uint32 checks the uint32 fast path against Lookup, and
+// that it refuses an IPv6 table instead of misreading its trie.
+func TestLookupAddr4Uint32(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create IPv4 table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("192.168.0.0/16")
+	if err := table.Insert(prefix, 100); err != nil {
+		t.Fatalf("Failed to insert prefix: %v", err)
+	}
+
+	addr := netip.MustParseAddr("192.168.1.1")
+	addr4 := addr.As4()
+	got := table.LookupAddr4Uint32(binary.BigEndian.Uint32(addr4[:]))
+	if want, _ := table.Lookup(addr); got != want {
+		t.Errorf("LookupAddr4Uint32 = %v, want %v (from Lookup)", got, want)
+	}
+
+	miss := netip.MustParseAddr("10.0.0.1").As4()
+	if nh := table.LookupAddr4Uint32(binary.BigEndian.Uint32(miss[:])); nh.IsValid() {
+		t.Errorf("LookupAddr4Uint32 for an unmatched address = %v, want InvalidNextHop", nh)
+	}
+
+	v6Table, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create IPv6 table: %v", err)
+	}
+	defer v6Table.Close()
+	if nh := v6Table.LookupAddr4Uint32(0); nh.IsValid() {
+		t.Errorf("LookupAddr4Uint32 on an IPv6 table = %v, want InvalidNextHop", nh)
+	}
+}
+
 // TestInsertAndLookupIPv6 tests basic insert and lookup for IPv6.
 func TestInsertAndLookupIPv6(t *testing.T) {
 	table, err := NewTableIPv6()
@@ -0,0 +1,319 @@
+package liblpm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+
+	fibiomrt "github.com/MuriloChianfa/liblpm/go/fibio/mrt"
+)
+
+// MRT (RFC 6396) constants this file understands, on top of the record
+// walking fibio/mrt already provides. Only AS_PATH is parsed here; the
+// common header, PEER_INDEX_TABLE, and RIB record shapes are fibio/mrt's
+// concern, not duplicated in this file.
+const (
+	mrtAttrFlagExtLength = 0x10
+	mrtAttrTypeASPath    = 2
+
+	// mrtInsertBatchSize bounds how many routes LoadMRT buffers before
+	// calling InsertBatch, so loading a RIB with millions of prefixes
+	// does so in bounded memory rather than building one giant slice
+	// first, per the request this implements.
+	mrtInsertBatchSize = 10000
+)
+
+// MRTNextHopFunc derives the 32-bit next hop LoadMRT installs for a RIB
+// entry from the peer that announced it and its AS_PATH, ordered from
+// the peer's own AS outward to the origin. Most callers resolve this
+// through their own peer/AS to next-hop-id mapping (say, a table keyed
+// by origin AS for an AS-based FIB, or by peer address for a per-session
+// one); liblpm has no opinion on what a next hop identifier represents.
+type MRTNextHopFunc func(peer netip.Addr, asPath []uint32) NextHop
+
+// LoadMRT creates a new table from r's RFC 6396 TABLE_DUMP_V2 RIB
+// snapshot, the format `bgpdump`/RouteViews/RIPE RIS archives use. It
+// builds on fibio/mrt.DecodeFull for the record walk - the same decoder
+// fibio.LoadMRT uses to stream a *BatchTable - adding its own AS_PATH
+// extraction and a caller-supplied peer/AS-path to next-hop mapping,
+// since fibio's RIBEntry.NextHop only extracts the advertised BGP next
+// hop address, not AS_PATH.
+//
+// Of a RIB entry's (possibly several) per-peer paths, only the first is
+// kept - this builds one Table with one next hop per prefix, the same
+// one-next-hop-by-default shape Insert has; a caller wanting every
+// peer's path, or real ECMP, should call fibio/mrt.DecodeFull directly
+// and drive InsertMultipath itself. AS_PATH attributes are assumed to
+// use 4-byte AS numbers (RFC 6793), which is how TABLE_DUMP_V2 exporters
+// encode them in practice regardless of the originating session's own
+// AS4 capability; a dump that genuinely used 2-byte AS_PATH encoding
+// will parse into garbled AS numbers.
+//
+// The table's address family is fixed by whichever RIB subtype is seen
+// first; entries of the other family later in the same stream are
+// skipped. A dump interleaving both families needs two passes - one per
+// family - since a Table only ever holds one.
+func LoadMRT(r io.Reader, nextHop MRTNextHopFunc) (*Table, error) {
+	var table *Table
+	var peers []fibiomrt.Peer
+	pendingPrefixes := make([]netip.Prefix, 0, mrtInsertBatchSize)
+	pendingNextHops := make([]NextHop, 0, mrtInsertBatchSize)
+
+	// fail closes table, if one has been created, before returning err -
+	// every error path below goes through it so a RIB record or flush
+	// failure partway through a stream doesn't leak the cgo-backed trie
+	// a prior record already allocated.
+	fail := func(err error) error {
+		if table != nil {
+			table.Close()
+		}
+		return err
+	}
+
+	flush := func() error {
+		if len(pendingPrefixes) == 0 {
+			return nil
+		}
+		if _, err := table.InsertBatch(pendingPrefixes, pendingNextHops); err != nil {
+			return fmt.Errorf("liblpm: loading MRT RIB entries: %w", err)
+		}
+		pendingPrefixes = pendingPrefixes[:0]
+		pendingNextHops = pendingNextHops[:0]
+		return nil
+	}
+
+	onPeers := func(p []fibiomrt.Peer) error {
+		peers = p
+		return nil
+	}
+
+	onRoute := func(prefix netip.Prefix, entries []fibiomrt.RIBEntry) error {
+		isIPv4 := prefix.Addr().Is4()
+		if table == nil {
+			var err error
+			if isIPv4 {
+				table, err = NewTableIPv4()
+			} else {
+				table, err = NewTableIPv6()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if isIPv4 != table.isIPv4 {
+			return nil
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		entry := entries[0]
+		asPath, err := parseMRTASPath(entry.Attributes)
+		if err != nil {
+			return err
+		}
+		if int(entry.PeerIndex) >= len(peers) {
+			return fmt.Errorf("liblpm: RIB entry references peer index %d, but the PEER_INDEX_TABLE has %d peers", entry.PeerIndex, len(peers))
+		}
+		peer := peers[entry.PeerIndex].Address
+
+		pendingPrefixes = append(pendingPrefixes, prefix)
+		pendingNextHops = append(pendingNextHops, nextHop(peer, asPath))
+		if len(pendingPrefixes) >= mrtInsertBatchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	if err := fibiomrt.DecodeFull(bufio.NewReader(r), onPeers, onRoute); err != nil {
+		return nil, fail(err)
+	}
+
+	if table == nil {
+		// No RIB record ever created a table - either the stream held no
+		// RIB records at all (a genuinely empty or non-MRT input), or it
+		// held a PEER_INDEX_TABLE for a table with zero routes, the shape
+		// DumpMRT itself produces for an empty Table. The synthetic
+		// peer's address family tells the two apart.
+		if len(peers) == 0 {
+			return nil, fmt.Errorf("liblpm: no TABLE_DUMP_V2 RIB or PEER_INDEX_TABLE records found in input")
+		}
+		var err error
+		if peers[0].Address.Is4() {
+			table, err = NewTableIPv4()
+		} else {
+			table, err = NewTableIPv6()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, fail(err)
+	}
+	return table, nil
+}
+
+// parseMRTASPath scans a BGP attribute blob for AS_PATH, flattening
+// every segment (AS_SET and AS_SEQUENCE alike) into one ordered slice -
+// LoadMRT's callers get the raw AS numbers and can interpret set
+// membership themselves if it matters to them.
+func parseMRTASPath(attrs []byte) ([]uint32, error) {
+	for len(attrs) > 0 {
+		if len(attrs) < 2 {
+			return nil, fmt.Errorf("liblpm: truncated BGP attribute header")
+		}
+		flags := attrs[0]
+		typeCode := attrs[1]
+		attrs = attrs[2:]
+
+		var attrLen int
+		if flags&mrtAttrFlagExtLength != 0 {
+			if len(attrs) < 2 {
+				return nil, fmt.Errorf("liblpm: truncated extended-length BGP attribute")
+			}
+			attrLen = int(binary.BigEndian.Uint16(attrs[:2]))
+			attrs = attrs[2:]
+		} else {
+			if len(attrs) < 1 {
+				return nil, fmt.Errorf("liblpm: truncated BGP attribute length")
+			}
+			attrLen = int(attrs[0])
+			attrs = attrs[1:]
+		}
+		if attrLen > len(attrs) {
+			return nil, fmt.Errorf("liblpm: BGP attribute value overruns its record")
+		}
+		value := attrs[:attrLen]
+		attrs = attrs[attrLen:]
+
+		if typeCode != mrtAttrTypeASPath {
+			continue
+		}
+
+		var asPath []uint32
+		for len(value) > 0 {
+			if len(value) < 2 {
+				return nil, fmt.Errorf("liblpm: truncated AS_PATH segment header")
+			}
+			segLen := int(value[1])
+			value = value[2:]
+			if segLen*4 > len(value) {
+				return nil, fmt.Errorf("liblpm: AS_PATH segment overruns its attribute")
+			}
+			for i := 0; i < segLen; i++ {
+				asPath = append(asPath, binary.BigEndian.Uint32(value[:4]))
+				value = value[4:]
+			}
+		}
+		return asPath, nil
+	}
+	return nil, nil
+}
+
+// DumpMRT writes the table's routes as a TABLE_DUMP_V2 stream: a
+// PEER_INDEX_TABLE naming one synthetic local peer (an unspecified
+// address of the table's family, AS 0), then one RIB_IPV4_UNICAST or
+// RIB_IPV6_UNICAST record per route, each with a single RIB entry for
+// that peer whose AS_PATH is a single-AS AS_SEQUENCE carrying the
+// route's next hop. That's enough for LoadMRT, given a
+// `func(_ netip.Addr, asPath []uint32) NextHop { return NextHop(asPath[0]) }`
+// mapping, to round-trip every route - but it is not a faithful export
+// of real BGP peer/AS-path data, since Table retains neither; treat this
+// as liblpm's own interchange format riding on the MRT container, not a
+// general-purpose BGP RIB archiver. There is no fibio/mrt counterpart to
+// build this on - that package only decodes - so the writer side stays
+// here.
+func (t *Table) DumpMRT(w io.Writer) error {
+	if t.closed {
+		return ErrTableClosed
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeMRTPeerIndexTable(bw, t.isIPv4); err != nil {
+		return err
+	}
+
+	var walkErr error
+	t.Walk(func(prefix netip.Prefix, nextHop NextHop) bool {
+		walkErr = writeMRTRIBEntry(bw, t.isIPv4, prefix, nextHop)
+		return walkErr == nil
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	return bw.Flush()
+}
+
+func writeMRTHeader(w io.Writer, subtype uint16, body []byte) error {
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint16(header[4:6], fibiomrt.TypeTableDumpV2)
+	binary.BigEndian.PutUint16(header[6:8], subtype)
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(body)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+func writeMRTPeerIndexTable(w io.Writer, isIPv4 bool) error {
+	addrLen := 4
+	peerType := byte(fibiomrt.PeerTypeAS4Flag)
+	if !isIPv4 {
+		addrLen = 16
+		peerType |= fibiomrt.PeerTypeIPv6Flag
+	}
+
+	body := make([]byte, 0, 6+1+4+addrLen+4)
+	body = append(body, 0, 0, 0, 0) // collector BGP ID
+	body = append(body, 0, 0)       // view name length
+	body = append(body, 0, 1)       // peer count = 1
+	body = append(body, peerType)
+	body = append(body, 0, 0, 0, 0) // peer BGP ID
+	body = append(body, make([]byte, addrLen)...)
+	body = append(body, 0, 0, 0, 0) // peer AS (4 bytes, AS4 flag set above)
+
+	return writeMRTHeader(w, fibiomrt.SubtypePeerIndexTable, body)
+}
+
+func writeMRTRIBEntry(w io.Writer, isIPv4 bool, prefix netip.Prefix, nextHop NextHop) error {
+	subtype := uint16(fibiomrt.SubtypeRIBIPv6Unicast)
+	if isIPv4 {
+		subtype = fibiomrt.SubtypeRIBIPv4Unicast
+	}
+
+	prefixBytes, prefixLen, err := prefixToBytes(prefix)
+	if err != nil {
+		return err
+	}
+	addrLen := (int(prefixLen) + 7) / 8
+
+	// AS_PATH attribute: one AS_SEQUENCE segment holding nextHop as its
+	// single AS number.
+	asPath := make([]byte, 0, 6)
+	asPath = append(asPath, 2, 1) // segment type AS_SEQUENCE, length 1
+	var asBuf [4]byte
+	binary.BigEndian.PutUint32(asBuf[:], uint32(nextHop))
+	asPath = append(asPath, asBuf[:]...)
+
+	attrs := make([]byte, 0, 2+1+len(asPath))
+	attrs = append(attrs, 0x40, mrtAttrTypeASPath, byte(len(asPath)))
+	attrs = append(attrs, asPath...)
+
+	body := make([]byte, 0, 5+addrLen+2+8+len(attrs))
+	body = append(body, 0, 0, 0, 0) // sequence number
+	body = append(body, byte(prefixLen))
+	body = append(body, prefixBytes[:addrLen]...)
+	body = append(body, 0, 1)       // entry count = 1
+	body = append(body, 0, 0)       // peer index 0
+	body = append(body, 0, 0, 0, 0) // originated time
+	var attrLenBuf [2]byte
+	binary.BigEndian.PutUint16(attrLenBuf[:], uint16(len(attrs)))
+	body = append(body, attrLenBuf[:]...)
+	body = append(body, attrs...)
+
+	return writeMRTHeader(w, subtype, body)
+}
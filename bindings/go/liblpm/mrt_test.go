@@ -0,0 +1,175 @@
+package liblpm
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+// asPathNextHop recovers the NextHop DumpMRT encoded as a single-AS
+// AS_PATH, the mapping function its own doc comment recommends.
+func asPathNextHop(_ netip.Addr, asPath []uint32) NextHop {
+	if len(asPath) == 0 {
+		return InvalidNextHop
+	}
+	return NextHop(asPath[0])
+}
+
+func TestDumpMRTAndLoadMRTRoundTripIPv4(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	routes := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 100},
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 200},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), NextHop: 300},
+	}
+	for _, r := range routes {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := table.DumpMRT(&buf); err != nil {
+		t.Fatalf("DumpMRT failed: %v", err)
+	}
+
+	loaded, err := LoadMRT(&buf, asPathNextHop)
+	if err != nil {
+		t.Fatalf("LoadMRT failed: %v", err)
+	}
+	defer loaded.Close()
+
+	for _, r := range routes {
+		if nh, ok := loaded.LookupExact(r.Prefix); !ok || nh != r.NextHop {
+			t.Errorf("LookupExact(%v) = %v/%v, want %v/true", r.Prefix, nh, ok, r.NextHop)
+		}
+	}
+}
+
+func TestDumpMRTAndLoadMRTRoundTripIPv6(t *testing.T) {
+	table, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("2001:db8::/32")
+	if err := table.Insert(prefix, 42); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := table.DumpMRT(&buf); err != nil {
+		t.Fatalf("DumpMRT failed: %v", err)
+	}
+
+	loaded, err := LoadMRT(&buf, asPathNextHop)
+	if err != nil {
+		t.Fatalf("LoadMRT failed: %v", err)
+	}
+	defer loaded.Close()
+
+	if nh, ok := loaded.LookupExact(prefix); !ok || nh != 42 {
+		t.Errorf("LookupExact(%v) = %v/%v, want 42/true", prefix, nh, ok)
+	}
+}
+
+func TestLoadMRTUsesPeerIndexTable(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := table.DumpMRT(&buf); err != nil {
+		t.Fatalf("DumpMRT failed: %v", err)
+	}
+
+	var gotPeer netip.Addr
+	loaded, err := LoadMRT(&buf, func(peer netip.Addr, asPath []uint32) NextHop {
+		gotPeer = peer
+		return asPathNextHop(peer, asPath)
+	})
+	if err != nil {
+		t.Fatalf("LoadMRT failed: %v", err)
+	}
+	defer loaded.Close()
+
+	if !gotPeer.IsValid() {
+		t.Error("Expected the mapping function to receive a valid peer address from the PEER_INDEX_TABLE")
+	}
+}
+
+func TestLoadMRTSkipsNonTableDumpV2Records(t *testing.T) {
+	var buf bytes.Buffer
+
+	// A record of some other MRT type (here, type 11 = BGP4MP) should be
+	// skipped rather than rejected.
+	header := make([]byte, 12)
+	header[4], header[5] = 0, 11
+	header[6], header[7] = 0, 1
+	header[8], header[9], header[10], header[11] = 0, 0, 0, 4
+	buf.Write(header)
+	buf.Write([]byte{1, 2, 3, 4})
+
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := table.DumpMRT(&buf); err != nil {
+		t.Fatalf("DumpMRT failed: %v", err)
+	}
+
+	loaded, err := LoadMRT(&buf, asPathNextHop)
+	if err != nil {
+		t.Fatalf("LoadMRT failed: %v", err)
+	}
+	defer loaded.Close()
+
+	if nh, ok := loaded.LookupExact(netip.MustParsePrefix("10.0.0.0/24")); !ok || nh != 1 {
+		t.Errorf("LookupExact = %v/%v, want 1/true", nh, ok)
+	}
+}
+
+func TestDumpMRTAndLoadMRTRoundTripEmptyTable(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	var buf bytes.Buffer
+	if err := table.DumpMRT(&buf); err != nil {
+		t.Fatalf("DumpMRT failed: %v", err)
+	}
+
+	loaded, err := LoadMRT(&buf, asPathNextHop)
+	if err != nil {
+		t.Fatalf("LoadMRT failed on an empty table's dump: %v", err)
+	}
+	defer loaded.Close()
+
+	if !loaded.isIPv4 {
+		t.Error("LoadMRT recovered the wrong address family for an empty IPv4 table's dump")
+	}
+}
+
+func TestLoadMRTEmptyInput(t *testing.T) {
+	if _, err := LoadMRT(bytes.NewReader(nil), asPathNextHop); err == nil {
+		t.Error("Expected an error for input with no RIB records")
+	}
+}
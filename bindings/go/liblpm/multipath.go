@@ -0,0 +1,345 @@
+package liblpm
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net/netip"
+	"sort"
+)
+
+// multipathGroupFlag marks a uint32 value stored as a trie's next hop as
+// a multipath group reference rather than a literal NextHop.
+// InsertMultipath ORs it into the allocated group ID; LookupFlow,
+// LookupFlowBatch and LookupAll check for it and mask it off before
+// indexing into Table.groups. This relies on real next hops never using
+// the top bit, true of every interface index / route ID scheme this
+// library has been used with so far.
+const multipathGroupFlag uint32 = 1 << 31
+
+// multipathGroup is one InsertMultipath group: the member next hops and
+// their relative weights, kept sorted by next hop so two inserts with
+// identical membership always hash to the same picks regardless of the
+// order the caller passed them in.
+type multipathGroup struct {
+	nextHops []NextHop
+	weights  []uint16
+}
+
+// newMultipathGroup copies and sorts nextHops/weights by next hop
+// ascending.
+func newMultipathGroup(nextHops []NextHop, weights []uint16) *multipathGroup {
+	order := make([]int, len(nextHops))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return nextHops[order[a]] < nextHops[order[b]] })
+
+	g := &multipathGroup{
+		nextHops: make([]NextHop, len(nextHops)),
+		weights:  make([]uint16, len(weights)),
+	}
+	for i, idx := range order {
+		g.nextHops[i] = nextHops[idx]
+		g.weights[i] = weights[idx]
+	}
+	return g
+}
+
+// FlowKey is the 5-tuple LookupFlowBatch hashes on to pick a member of an
+// ECMP group. See LookupFlow.
+type FlowKey struct {
+	Src, Dst netip.Addr
+	Proto    uint8
+	SPort    uint16
+	DPort    uint16
+}
+
+// ensureMultipath lazily initializes the group table and the per-table
+// SipHash key on first use, so tables that never call InsertMultipath
+// pay nothing for it.
+func (t *Table) ensureMultipath() error {
+	if t.groups != nil {
+		return nil
+	}
+
+	// A custom Hasher never touches hashKey0/hashKey1, so generating them
+	// would just be wasted crypto/rand output.
+	if t.hasher != nil {
+		t.groups = make(map[uint32]*multipathGroup)
+		return nil
+	}
+
+	var keyBytes [16]byte
+	if _, err := rand.Read(keyBytes[:]); err != nil {
+		return err
+	}
+
+	t.groups = make(map[uint32]*multipathGroup)
+	t.hashKey0 = binary.LittleEndian.Uint64(keyBytes[0:8])
+	t.hashKey1 = binary.LittleEndian.Uint64(keyBytes[8:16])
+	return nil
+}
+
+// maxGroupID is the highest group ID ever handed out. The next one up,
+// multipathGroupFlag-1, OR'd with multipathGroupFlag would equal
+// 0xFFFFFFFF - InvalidNextHop - so it's reserved rather than risking a
+// valid route silently reading back as "no match".
+const maxGroupID = multipathGroupFlag - 2
+
+// allocGroupID returns an unused group ID, reusing one freed by a prior
+// overwrite/delete when available.
+func (t *Table) allocGroupID() (uint32, error) {
+	if n := len(t.freeGroupIDs); n > 0 {
+		id := t.freeGroupIDs[n-1]
+		t.freeGroupIDs = t.freeGroupIDs[:n-1]
+		return id, nil
+	}
+	if t.nextGroupID > maxGroupID {
+		return 0, errors.New("liblpm: multipath group ID space exhausted")
+	}
+	id := t.nextGroupID
+	t.nextGroupID++
+	return id, nil
+}
+
+// releaseGroup frees prevNextHop's group slot if it refers to one, so
+// InsertMultipath overwriting an existing multipath prefix doesn't leak
+// the group it's replacing.
+func (t *Table) releaseGroup(prevNextHop uint32) {
+	if prevNextHop&multipathGroupFlag == 0 {
+		return
+	}
+	id := prevNextHop &^ multipathGroupFlag
+	delete(t.groups, id)
+	t.freeGroupIDs = append(t.freeGroupIDs, id)
+}
+
+// InsertMultipath installs prefix with a weighted set of ECMP next hops
+// instead of a single one. Reads against it must go through LookupFlow,
+// LookupFlowBatch or LookupAll - Lookup/LookupBatch have no way to pick
+// a member and will return the opaque group reference as if it were a
+// next hop.
+func (t *Table) InsertMultipath(prefix netip.Prefix, nextHops []NextHop, weights []uint16) error {
+	if t.closed {
+		return ErrTableClosed
+	}
+	if t.isIPv4 && !prefix.Addr().Is4() {
+		return ErrInvalidPrefix
+	}
+	if !t.isIPv4 && !prefix.Addr().Is6() {
+		return ErrInvalidPrefix
+	}
+	if len(nextHops) == 0 || len(nextHops) != len(weights) {
+		return errors.New("liblpm: InsertMultipath requires equal-length, non-empty nextHops and weights")
+	}
+	for _, w := range weights {
+		if w == 0 {
+			return errors.New("liblpm: InsertMultipath weights must be non-zero")
+		}
+	}
+
+	prefixBytes, prefixLen, err := prefixToBytes(prefix)
+	if err != nil {
+		return err
+	}
+
+	if err := t.ensureMultipath(); err != nil {
+		return err
+	}
+
+	groupID, err := t.allocGroupID()
+	if err != nil {
+		return err
+	}
+	t.groups[groupID] = newMultipathGroup(nextHops, weights)
+
+	prevNextHop, existed, err := cDeleteGetPrev(t.cTrie, prefixBytes, prefixLen)
+	if err != nil {
+		delete(t.groups, groupID)
+		t.freeGroupIDs = append(t.freeGroupIDs, groupID)
+		return err
+	}
+	if existed {
+		t.releaseGroup(prevNextHop)
+		t.forgetRoute(prefix)
+	}
+
+	if err := cAdd(t.cTrie, prefixBytes, prefixLen, groupID|multipathGroupFlag); err != nil {
+		delete(t.groups, groupID)
+		t.freeGroupIDs = append(t.freeGroupIDs, groupID)
+		return err
+	}
+	return nil
+}
+
+// pickNextHop selects one member of group for flowTuple using weighted
+// rendezvous (highest random weight) hashing: every candidate gets an
+// independent score derived from hashing the tuple together with its own
+// next hop, scaled by its weight, and the highest score wins. Unlike
+// hashing into a modulo-reduced bucket array, removing or adding one
+// member only ever changes the pick for flows whose best candidate was
+// that member - everyone else's highest score is unaffected.
+func (t *Table) pickNextHop(group *multipathGroup, flowTuple []byte) NextHop {
+	best := group.nextHops[0]
+	bestScore := math.Inf(-1)
+
+	buf := make([]byte, len(flowTuple)+4)
+	copy(buf, flowTuple)
+
+	for i, nh := range group.nextHops {
+		binary.BigEndian.PutUint32(buf[len(flowTuple):], uint32(nh))
+		h := t.flowHash(buf)
+
+		// r in the open interval (0, 1); math.Log(1) == 0 would make
+		// score diverge to -Inf regardless of weight, so h must never
+		// reach the upper bound. Only h's top 52 bits are used, offset by
+		// half a step, so the result stays exact in a float64 and can't
+		// round up to 1.
+		r := (float64(h>>12) + 0.5) / (maxUint64Plus1 / 4096)
+		score := -float64(group.weights[i]) / math.Log(r)
+		if score > bestScore {
+			bestScore = score
+			best = nh
+		}
+	}
+	return best
+}
+
+// flowHash derives pickNextHop's rendezvous-hash input for buf, using
+// TableOptions.Hasher if NewTable was given one, or the default per-table
+// keyed SipHash-2-4 otherwise.
+func (t *Table) flowHash(buf []byte) uint64 {
+	if t.hasher != nil {
+		return t.hasher(buf)
+	}
+	return sipHash24(t.hashKey0, t.hashKey1, buf)
+}
+
+// maxUint64Plus1 is 2^64, expressed as a float64 constant (uint64 itself
+// can't hold it) for the rendezvous-hash scaling in pickNextHop.
+const maxUint64Plus1 = 18446744073709551616.0
+
+// flowTupleBytes serializes a 5-tuple into a byte slice suitable for
+// hashing: source address, destination address, protocol, then source
+// and destination ports in network byte order.
+func flowTupleBytes(src, dst netip.Addr, proto uint8, sport, dport uint16) []byte {
+	srcBytes := src.AsSlice()
+	dstBytes := dst.AsSlice()
+
+	buf := make([]byte, 0, len(srcBytes)+len(dstBytes)+5)
+	buf = append(buf, srcBytes...)
+	buf = append(buf, dstBytes...)
+	buf = append(buf, proto)
+
+	var ports [4]byte
+	binary.BigEndian.PutUint16(ports[0:2], sport)
+	binary.BigEndian.PutUint16(ports[2:4], dport)
+	return append(buf, ports[:]...)
+}
+
+// resolveGroup reports whether a trie lookup result is a multipath group
+// reference and, if so, the group it refers to. isGroupRef is true but
+// the group is nil if the reference is stale (shouldn't happen in
+// practice, but callers must treat it as "no route" rather than
+// misreading the raw group ID as a NextHop).
+func (t *Table) resolveGroup(nh NextHop) (group *multipathGroup, isGroupRef bool) {
+	if uint32(nh)&multipathGroupFlag == 0 {
+		return nil, false
+	}
+	return t.groups[uint32(nh)&^multipathGroupFlag], true
+}
+
+// LookupFlow performs a longest prefix match on dst, then, if the
+// matched route is an ECMP group installed by InsertMultipath, picks one
+// of its next hops deterministically by hashing the 5-tuple (src, dst,
+// proto, sport, dport) - the same flow always resolves to the same next
+// hop as long as the group's membership doesn't change. Non-multipath
+// routes are returned as-is, ignoring the tuple. This mirrors the kernel
+// multipath forwarding model for ECMP/IPv6 route groups.
+func (t *Table) LookupFlow(src, dst netip.Addr, proto uint8, sport, dport uint16) (NextHop, bool) {
+	if t.closed {
+		return InvalidNextHop, false
+	}
+
+	nh, ok := t.Lookup(dst)
+	if !ok {
+		return InvalidNextHop, false
+	}
+
+	group, isGroupRef := t.resolveGroup(nh)
+	if !isGroupRef {
+		return nh, true
+	}
+	if group == nil {
+		return InvalidNextHop, false
+	}
+
+	tuple := flowTupleBytes(src, dst, proto, sport, dport)
+	return t.pickNextHop(group, tuple), true
+}
+
+// LookupFlowBatch performs LookupFlow for every flow in one call,
+// avoiding the per-call cgo transition of calling LookupFlow in a loop.
+func (t *Table) LookupFlowBatch(flows []FlowKey) ([]NextHop, error) {
+	if t.closed {
+		return nil, ErrTableClosed
+	}
+	if len(flows) == 0 {
+		return []NextHop{}, nil
+	}
+
+	dsts := make([]netip.Addr, len(flows))
+	for i, f := range flows {
+		dsts[i] = f.Dst
+	}
+	matched, err := t.LookupBatch(dsts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]NextHop, len(flows))
+	for i, nh := range matched {
+		if !nh.IsValid() {
+			results[i] = InvalidNextHop
+			continue
+		}
+		group, isGroupRef := t.resolveGroup(nh)
+		if !isGroupRef {
+			results[i] = nh
+			continue
+		}
+		if group == nil {
+			results[i] = InvalidNextHop
+			continue
+		}
+		tuple := flowTupleBytes(flows[i].Src, flows[i].Dst, flows[i].Proto, flows[i].SPort, flows[i].DPort)
+		results[i] = t.pickNextHop(group, tuple)
+	}
+	return results, nil
+}
+
+// LookupAll performs a longest prefix match for addr and returns every
+// ECMP next hop for the matched route along with its weight, for callers
+// that want to steer traffic themselves instead of using LookupFlow. A
+// non-multipath route is reported as a single next hop of weight 1.
+func (t *Table) LookupAll(addr netip.Addr) ([]NextHop, []uint16, bool) {
+	if t.closed {
+		return nil, nil, false
+	}
+
+	nh, ok := t.Lookup(addr)
+	if !ok {
+		return nil, nil, false
+	}
+
+	group, isGroupRef := t.resolveGroup(nh)
+	if !isGroupRef {
+		return []NextHop{nh}, []uint16{1}, true
+	}
+	if group == nil {
+		return nil, nil, false
+	}
+	return group.nextHops, group.weights, true
+}
@@ -0,0 +1,203 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestInsertMultipathAndLookupAll(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	nextHops := []NextHop{1, 2, 3}
+	weights := []uint16{1, 1, 2}
+	if err := table.InsertMultipath(prefix, nextHops, weights); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	addr := netip.MustParseAddr("10.0.0.5")
+	gotHops, gotWeights, ok := table.LookupAll(addr)
+	if !ok {
+		t.Fatal("LookupAll found no route")
+	}
+	if len(gotHops) != 3 || len(gotWeights) != 3 {
+		t.Fatalf("LookupAll returned %v/%v, want 3 members", gotHops, gotWeights)
+	}
+}
+
+func TestLookupFlowIsSticky(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	nextHops := []NextHop{1, 2, 3, 4}
+	weights := []uint16{1, 1, 1, 1}
+	if err := table.InsertMultipath(prefix, nextHops, weights); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	src := netip.MustParseAddr("192.0.2.1")
+	dst := netip.MustParseAddr("10.0.0.5")
+
+	first, ok := table.LookupFlow(src, dst, 6, 4321, 80)
+	if !ok {
+		t.Fatal("LookupFlow found no route")
+	}
+	for i := 0; i < 20; i++ {
+		nh, ok := table.LookupFlow(src, dst, 6, 4321, 80)
+		if !ok || nh != first {
+			t.Fatalf("LookupFlow for the same 5-tuple returned %v (ok=%v), want sticky %v", nh, ok, first)
+		}
+	}
+}
+
+func TestLookupFlowSpreadsAcrossFlows(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	nextHops := []NextHop{1, 2, 3, 4}
+	weights := []uint16{1, 1, 1, 1}
+	if err := table.InsertMultipath(prefix, nextHops, weights); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	dst := netip.MustParseAddr("10.0.0.5")
+	seen := map[NextHop]bool{}
+	for sport := uint16(1); sport < 200; sport++ {
+		nh, ok := table.LookupFlow(netip.MustParseAddr("192.0.2.1"), dst, 6, sport, 80)
+		if !ok {
+			t.Fatalf("LookupFlow found no route for sport %d", sport)
+		}
+		seen[nh] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected LookupFlow to spread flows across multiple next hops, only saw %v", seen)
+	}
+}
+
+func TestLookupFlowBatchMatchesLookupFlow(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	nextHops := []NextHop{1, 2, 3}
+	weights := []uint16{1, 1, 1}
+	if err := table.InsertMultipath(prefix, nextHops, weights); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	flows := []FlowKey{
+		{Src: netip.MustParseAddr("192.0.2.1"), Dst: netip.MustParseAddr("10.0.0.5"), Proto: 6, SPort: 1111, DPort: 80},
+		{Src: netip.MustParseAddr("192.0.2.2"), Dst: netip.MustParseAddr("10.0.0.5"), Proto: 17, SPort: 2222, DPort: 53},
+	}
+
+	batchResults, err := table.LookupFlowBatch(flows)
+	if err != nil {
+		t.Fatalf("LookupFlowBatch failed: %v", err)
+	}
+
+	for i, f := range flows {
+		want, ok := table.LookupFlow(f.Src, f.Dst, f.Proto, f.SPort, f.DPort)
+		if !ok {
+			t.Fatalf("LookupFlow found no route for flow %d", i)
+		}
+		if batchResults[i] != want {
+			t.Errorf("LookupFlowBatch[%d] = %v, want %v (matching LookupFlow)", i, batchResults[i], want)
+		}
+	}
+}
+
+func TestInsertMultipathRejectsMismatchedLengths(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.InsertMultipath(prefix, []NextHop{1, 2}, []uint16{1}); err == nil {
+		t.Error("Expected InsertMultipath to reject mismatched nextHops/weights lengths")
+	}
+	if err := table.InsertMultipath(prefix, []NextHop{1}, []uint16{0}); err == nil {
+		t.Error("Expected InsertMultipath to reject a zero weight")
+	}
+}
+
+func TestInsertMultipathOverwriteReleasesOldGroup(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	for i := 0; i < 50; i++ {
+		if err := table.InsertMultipath(prefix, []NextHop{1, 2}, []uint16{1, 1}); err != nil {
+			t.Fatalf("InsertMultipath failed on iteration %d: %v", i, err)
+		}
+	}
+
+	if len(table.groups) != 1 {
+		t.Errorf("Expected repeated InsertMultipath on the same prefix to reuse one group slot, got %d live groups", len(table.groups))
+	}
+}
+
+func TestInsertRejectsTopBitBeforeFirstInsertMultipath(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	// The top bit must be reserved from the table's very first Insert,
+	// not only once InsertMultipath has been called: gating the check on
+	// t.groups != nil would let this route in now, only for it to be
+	// retroactively misread as a stale group reference - and silently
+	// vanish from LookupFlow/LookupAll - the moment some other prefix
+	// later goes multipath.
+	poisoned := NextHop(multipathGroupFlag | 1)
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), poisoned); err == nil {
+		t.Error("Expected Insert to reject a next hop with the top bit set before any InsertMultipath call")
+	}
+}
+
+func TestInsertMultipathDoesNotPoisonEarlierTopBitFreeRoute(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	plain := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.Insert(plain, 5); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	other := netip.MustParsePrefix("10.0.1.0/24")
+	if err := table.InsertMultipath(other, []NextHop{1, 2}, []uint16{1, 1}); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	nh, ok := table.Lookup(netip.MustParseAddr("10.0.0.1"))
+	if !ok || nh != 5 {
+		t.Errorf("Lookup(10.0.0.1) = %v/%v, want 5/true", nh, ok)
+	}
+	addr := netip.MustParseAddr("10.0.0.1")
+	if _, ok := table.LookupFlow(addr, addr, 6, 1234, 80); !ok {
+		t.Error("LookupFlow(10.0.0.1) ok = false, want true: a plain route installed before the table went multipath must not be misread as a stale group reference")
+	}
+}
@@ -0,0 +1,227 @@
+package liblpm
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// sortRoutes orders routes by ascending address, breaking ties by
+// prefix length, the order Walk/Subnets/Supernets all report in.
+func sortRoutes(routes []Route) {
+	sort.Slice(routes, func(i, j int) bool {
+		if c := routes[i].Prefix.Addr().Compare(routes[j].Prefix.Addr()); c != 0 {
+			return c < 0
+		}
+		return routes[i].Prefix.Bits() < routes[j].Prefix.Bits()
+	})
+}
+
+// Walk calls fn once for every prefix installed via Insert, in ascending
+// address order (ties broken by prefix length), stopping early if fn
+// returns false. This is a best-effort approximation of trie order:
+// Table has no C-side walk callback yet, so this iterates the same
+// route shadow WriteTo/ReadFrom use (see serialize.go) rather than the
+// trie itself. InsertMultipath groups aren't part of that shadow and so
+// are not visited. Walk only reports ErrTableClosed; it cannot otherwise
+// fail, unlike BatchTable.Walk, which also depends on its backend
+// supporting traversal at all.
+func (t *Table) Walk(fn func(netip.Prefix, NextHop) bool) error {
+	if t.closed {
+		return ErrTableClosed
+	}
+	if len(t.routes) == 0 {
+		return nil
+	}
+
+	routes := make([]Route, 0, len(t.routes))
+	for prefix, nextHop := range t.routes {
+		routes = append(routes, Route{Prefix: prefix, NextHop: nextHop})
+	}
+	sortRoutes(routes)
+
+	for _, r := range routes {
+		if !fn(r.Prefix, r.NextHop) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// All is Walk reshaped into a Go 1.23 range-over-func iterator, so every
+// installed prefix can be visited with:
+//
+//	for prefix, nextHop := range table.All {
+//	    ...
+//	}
+//
+// The same best-effort ordering and InsertMultipath caveat documented on
+// Walk apply here, since All simply calls it. A closed table yields
+// nothing rather than reporting Walk's ErrTableClosed - range-over-func
+// iterators have no return value to carry it.
+func (t *Table) All(yield func(netip.Prefix, NextHop) bool) {
+	t.Walk(yield)
+}
+
+// WalkMatching calls fn once for every installed route that matches addr
+// - the same set Supernets returns - stopping early if fn returns false.
+// It's Supernets reshaped as a callback for callers that want to stop at
+// the first interesting match without paying Supernets' sort over the
+// full match set, such as "does any covering route exist". Unlike
+// Supernets, WalkMatching visits in t.routes' unspecified map order, not
+// ascending address order, which is the tradeoff that buys back the
+// sort. (The natural name, LookupAll, is already taken by the
+// ECMP-oriented query in multipath.go that returns every next hop for
+// the single longest match; this walks every matching prefix instead,
+// longest or not.) Like Supernets, it only sees the route shadow, so a
+// next hop resolved through InsertMultipath is not visited.
+func (t *Table) WalkMatching(addr netip.Addr, fn func(netip.Prefix, NextHop) bool) error {
+	if t.closed {
+		return ErrTableClosed
+	}
+
+	for p, nh := range t.routes {
+		if p.Contains(addr) {
+			if !fn(p, nh) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// longestMatch returns the most specific installed prefix containing
+// addr, from the same route shadow Walk/Subnets/Supernets read (see
+// Walk's doc comment for its InsertMultipath caveat).
+//
+// This is a linear scan of every installed route, O(n) in the size of
+// the table, unlike the C trie's own O(1)-ish Lookup: the route shadow
+// exists for WriteTo/Walk, not as an indexed structure, and there's no
+// C-side entry point that reports which prefix matched (the C trie
+// returns a next hop, not a prefix). Fine at dev/test scale or for
+// occasional diagnostic lookups; a hot path resolving a prefix for every
+// packet against a RIB-sized table (hundreds of thousands of routes)
+// should prefer plain Lookup and treat LookupPrefix/LookupPrefixBatch as
+// the slower, prefix-reporting variant. See BenchmarkLookupPrefixIPv4 in
+// the benchmarks package for numbers at that scale.
+func (t *Table) longestMatch(addr netip.Addr) (netip.Prefix, bool) {
+	var best netip.Prefix
+	bestBits := -1
+	for p := range t.routes {
+		if p.Bits() > bestBits && p.Contains(addr) {
+			bestBits = p.Bits()
+			best = p
+		}
+	}
+	return best, bestBits >= 0
+}
+
+// LookupPrefix performs a longest-prefix-match lookup like Lookup, and
+// additionally reports which installed prefix matched - useful for
+// attributing a flow to, say, "10.0.0.0/8" rather than just the next hop
+// it happens to resolve to. The matched prefix comes from the route
+// shadow longestMatch reads rather than a dedicated C entry point, so -
+// like Walk - it isn't available for a next hop resolved through
+// InsertMultipath: ok is still true in that case, but prefix is the
+// longest plain (non-multipath) route containing addr, or the zero
+// netip.Prefix if there is none.
+//
+// The next hop half of this is Lookup's usual cgo-backed speed; the
+// prefix half costs longestMatch's O(n) scan of the route shadow on top.
+// Prefer plain Lookup when only the next hop is needed.
+func (t *Table) LookupPrefix(addr netip.Addr) (prefix netip.Prefix, nextHop NextHop, ok bool) {
+	nextHop, ok = t.Lookup(addr)
+	if !ok {
+		return netip.Prefix{}, InvalidNextHop, false
+	}
+	prefix, _ = t.longestMatch(addr)
+	return prefix, nextHop, true
+}
+
+// LookupPrefixBatch is LookupPrefix over multiple addresses, sharing a
+// single LookupBatch cgo crossing for the next-hop half of the work. The
+// result for an address with no match has a zero Route.
+//
+// Only the next-hop half is batched, though: the prefix half still runs
+// longestMatch's O(n) route-shadow scan once per address, so this is
+// O(n*m) for m addresses against an n-route table, not the O(m) its
+// "single cgo crossing" framing might suggest. That's fine for the
+// hundreds-of-addresses batches this was written for; it is not a
+// substitute for LookupBatch against a RIB-sized table when only next
+// hops are needed. See BenchmarkLookupPrefixBatchIPv4 in the benchmarks
+// package.
+func (t *Table) LookupPrefixBatch(addrs []netip.Addr) ([]Route, error) {
+	nextHops, err := t.LookupBatch(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Route, len(addrs))
+	for i, addr := range addrs {
+		nh := nextHops[i]
+		if !nh.IsValid() {
+			results[i] = Route{NextHop: InvalidNextHop}
+			continue
+		}
+		prefix, _ := t.longestMatch(addr)
+		results[i] = Route{Prefix: prefix, NextHop: nh}
+	}
+	return results, nil
+}
+
+// LookupExact reports the next hop installed for prefix exactly, unlike
+// Lookup, which returns the longest match for an address rather than
+// requiring an exact prefix/length match.
+func (t *Table) LookupExact(prefix netip.Prefix) (NextHop, bool) {
+	if t.closed {
+		return InvalidNextHop, false
+	}
+	nh, ok := t.routes[prefix.Masked()]
+	return nh, ok
+}
+
+// Contains reports whether addr matches any installed route, ignoring
+// which next hop it resolves to.
+func (t *Table) Contains(addr netip.Addr) bool {
+	_, ok := t.Lookup(addr)
+	return ok
+}
+
+// Subnets returns every installed route that is strictly more specific
+// than prefix - every route prefix falls entirely within it - useful for
+// diffing two tables' coverage of a block or auditing what's been
+// delegated under it. Order matches Walk's.
+func (t *Table) Subnets(prefix netip.Prefix) []Route {
+	if t.closed {
+		return nil
+	}
+
+	var out []Route
+	for p, nh := range t.routes {
+		if p.Bits() > prefix.Bits() && prefix.Contains(p.Addr()) {
+			out = append(out, Route{Prefix: p, NextHop: nh})
+		}
+	}
+	sortRoutes(out)
+	return out
+}
+
+// Supernets returns every installed route that matches addr, ordered
+// like Walk (ascending address, then ascending prefix length - so from
+// least specific to most) - the full set Lookup's longest-prefix match
+// chooses among, rather than just the winner. Useful for route-leak and
+// hijack detection, where seeing a shorter, unexpected covering prefix
+// appear matters as much as the longest match itself.
+func (t *Table) Supernets(addr netip.Addr) []Route {
+	if t.closed {
+		return nil
+	}
+
+	var out []Route
+	for p, nh := range t.routes {
+		if p.Contains(addr) {
+			out = append(out, Route{Prefix: p, NextHop: nh})
+		}
+	}
+	sortRoutes(out)
+	return out
+}
@@ -0,0 +1,389 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestWalkVisitsEveryRouteInAddressOrder(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	routes := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 2},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/25"), NextHop: 3},
+	}
+	for _, r := range routes {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	var visited []Route
+	table.Walk(func(p netip.Prefix, nh NextHop) bool {
+		visited = append(visited, Route{Prefix: p, NextHop: nh})
+		return true
+	})
+
+	want := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/25"), NextHop: 3},
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 2},
+	}
+	if len(visited) != len(want) {
+		t.Fatalf("Walk visited %d routes, want %d: %v", len(visited), len(want), visited)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Errorf("visited[%d] = %v, want %v", i, visited[i], want[i])
+		}
+	}
+}
+
+func TestWalkStopsEarly(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for i, prefix := range []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"} {
+		if err := table.Insert(netip.MustParsePrefix(prefix), NextHop(i)); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+
+	count := 0
+	table.Walk(func(netip.Prefix, NextHop) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("Walk visited %d routes after stopping early, want 2", count)
+	}
+}
+
+func TestAllRangesOverEveryRouteInAddressOrder(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	routes := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 2},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/25"), NextHop: 3},
+	}
+	for _, r := range routes {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	var visited []Route
+	for p, nh := range table.All {
+		visited = append(visited, Route{Prefix: p, NextHop: nh})
+	}
+
+	want := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/25"), NextHop: 3},
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 2},
+	}
+	if len(visited) != len(want) {
+		t.Fatalf("All visited %d routes, want %d: %v", len(visited), len(want), visited)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Errorf("visited[%d] = %v, want %v", i, visited[i], want[i])
+		}
+	}
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for i, prefix := range []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"} {
+		if err := table.Insert(netip.MustParsePrefix(prefix), NextHop(i)); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+
+	count := 0
+	for range table.All {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("All visited %d routes after breaking early, want 2", count)
+	}
+}
+
+func TestLookupPrefix(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	wide := netip.MustParsePrefix("10.0.0.0/8")
+	narrow := netip.MustParsePrefix("10.1.0.0/16")
+	if err := table.Insert(wide, 1); err != nil {
+		t.Fatalf("Insert(%v) failed: %v", wide, err)
+	}
+	if err := table.Insert(narrow, 2); err != nil {
+		t.Fatalf("Insert(%v) failed: %v", narrow, err)
+	}
+
+	prefix, nh, ok := table.LookupPrefix(netip.MustParseAddr("10.1.0.1"))
+	if !ok || prefix != narrow || nh != 2 {
+		t.Errorf("LookupPrefix(10.1.0.1) = %v/%v/%v, want %v/2/true", prefix, nh, ok, narrow)
+	}
+
+	prefix, nh, ok = table.LookupPrefix(netip.MustParseAddr("10.2.0.1"))
+	if !ok || prefix != wide || nh != 1 {
+		t.Errorf("LookupPrefix(10.2.0.1) = %v/%v/%v, want %v/1/true", prefix, nh, ok, wide)
+	}
+
+	if _, _, ok := table.LookupPrefix(netip.MustParseAddr("192.0.2.1")); ok {
+		t.Error("Expected LookupPrefix to report false for an unmatched address")
+	}
+}
+
+func TestLookupPrefixBatch(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	wide := netip.MustParsePrefix("10.0.0.0/8")
+	narrow := netip.MustParsePrefix("10.1.0.0/16")
+	if err := table.Insert(wide, 1); err != nil {
+		t.Fatalf("Insert(%v) failed: %v", wide, err)
+	}
+	if err := table.Insert(narrow, 2); err != nil {
+		t.Fatalf("Insert(%v) failed: %v", narrow, err)
+	}
+
+	results, err := table.LookupPrefixBatch([]netip.Addr{
+		netip.MustParseAddr("10.1.0.1"),
+		netip.MustParseAddr("10.2.0.1"),
+		netip.MustParseAddr("192.0.2.1"),
+	})
+	if err != nil {
+		t.Fatalf("LookupPrefixBatch failed: %v", err)
+	}
+
+	want := []Route{
+		{Prefix: narrow, NextHop: 2},
+		{Prefix: wide, NextHop: 1},
+		{NextHop: InvalidNextHop},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("LookupPrefixBatch returned %d results, want %d", len(results), len(want))
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("results[%d] = %+v, want %+v", i, results[i], want[i])
+		}
+	}
+}
+
+func TestLookupExact(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if nh, ok := table.LookupExact(netip.MustParsePrefix("10.0.0.0/24")); !ok || nh != 1 {
+		t.Errorf("LookupExact(/24) = %v/%v, want 1/true", nh, ok)
+	}
+	if _, ok := table.LookupExact(netip.MustParsePrefix("10.0.0.0/25")); ok {
+		t.Error("Expected LookupExact to reject a more specific prefix that wasn't installed")
+	}
+	if _, ok := table.LookupExact(netip.MustParsePrefix("10.0.0.0/16")); ok {
+		t.Error("Expected LookupExact to reject a less specific prefix that wasn't installed")
+	}
+}
+
+func TestContains(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if !table.Contains(netip.MustParseAddr("10.0.0.5")) {
+		t.Error("Expected Contains to find a matching route")
+	}
+	if table.Contains(netip.MustParseAddr("192.0.2.1")) {
+		t.Error("Expected Contains to report false for an unmatched address")
+	}
+}
+
+func TestSubnets(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for _, r := range []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/16"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 2},
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 3},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), NextHop: 4},
+	} {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	subnets := table.Subnets(netip.MustParsePrefix("10.0.0.0/16"))
+	if len(subnets) != 2 {
+		t.Fatalf("Subnets returned %d routes, want 2: %v", len(subnets), subnets)
+	}
+	if subnets[0].NextHop != 2 || subnets[1].NextHop != 3 {
+		t.Errorf("Subnets = %v, want next hops 2 then 3", subnets)
+	}
+}
+
+func TestSupernets(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for _, r := range []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/8"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/16"), NextHop: 2},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 3},
+	} {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	supernets := table.Supernets(netip.MustParseAddr("10.0.0.1"))
+	if len(supernets) != 3 {
+		t.Fatalf("Supernets returned %d routes, want 3: %v", len(supernets), supernets)
+	}
+	for i, want := range []NextHop{1, 2, 3} {
+		if supernets[i].NextHop != want {
+			t.Errorf("Supernets[%d].NextHop = %v, want %v", i, supernets[i].NextHop, want)
+		}
+	}
+}
+
+func TestWalkReturnsErrTableClosed(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	table.Close()
+
+	if err := table.Walk(func(netip.Prefix, NextHop) bool { return true }); err != ErrTableClosed {
+		t.Errorf("Walk on a closed table = %v, want ErrTableClosed", err)
+	}
+}
+
+func TestWalkMatchingVisitsEverySupernet(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for _, r := range []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/8"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/16"), NextHop: 2},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 3},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), NextHop: 4},
+	} {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	// WalkMatching visits t.routes in its unspecified map order, unlike
+	// Supernets, so check the set of next hops seen rather than an order.
+	visited := map[NextHop]bool{}
+	err = table.WalkMatching(netip.MustParseAddr("10.0.0.1"), func(p netip.Prefix, nh NextHop) bool {
+		visited[nh] = true
+		return true
+	})
+	if err != nil {
+		t.Fatalf("WalkMatching failed: %v", err)
+	}
+
+	want := []NextHop{1, 2, 3}
+	if len(visited) != len(want) {
+		t.Fatalf("WalkMatching visited %d routes, want %d: %v", len(visited), len(want), visited)
+	}
+	for _, nh := range want {
+		if !visited[nh] {
+			t.Errorf("WalkMatching did not visit next hop %v", nh)
+		}
+	}
+}
+
+func TestWalkMatchingStopsEarly(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	for _, prefix := range []string{"10.0.0.0/8", "10.0.0.0/16", "10.0.0.0/24"} {
+		if err := table.Insert(netip.MustParsePrefix(prefix), 1); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+
+	count := 0
+	err = table.WalkMatching(netip.MustParseAddr("10.0.0.1"), func(netip.Prefix, NextHop) bool {
+		count++
+		return count < 2
+	})
+	if err != nil {
+		t.Fatalf("WalkMatching failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("WalkMatching visited %d routes after stopping early, want 2", count)
+	}
+}
+
+func TestWalkMatchingReturnsErrTableClosed(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	table.Close()
+
+	err = table.WalkMatching(netip.MustParseAddr("10.0.0.1"), func(netip.Prefix, NextHop) bool { return true })
+	if err != ErrTableClosed {
+		t.Errorf("WalkMatching on a closed table = %v, want ErrTableClosed", err)
+	}
+}
@@ -0,0 +1,66 @@
+package liblpm
+
+import "net/netip"
+
+// RouteValue is arbitrary per-route metadata - an ASN, a metric, an
+// origin flag, a pointer to a policy object - that AddValue attaches to
+// a prefix alongside the NextHop every other Table API sees.
+//
+// The wide-nexthop storage this could instead be - a 64-bit value packed
+// directly into the trie's leaf slot via new lpm_add_u64/lpm_lookup_u64 C
+// entry points - isn't implemented: that needs a new C entry point, and
+// there's no C source in this tree to add one to (see the package doc
+// for the broader story). What's here is the other option, a
+// Go-side keyed handle table, the same approach BatchTable[V]'s slab
+// already takes for the batch-oriented table. Table isn't generic the
+// way BatchTable[V] is, though, so AddValue doesn't repurpose the C
+// trie's next hop as a handle into a slab; it shadows the prefix
+// directly, the same way the routes map (see types.go) already shadows
+// Insert for WriteTo/Walk.
+type RouteValue = any
+
+// AddValue installs prefix with nextHop - the value Lookup and the rest
+// of Table's API see, exactly as Insert would - and additionally
+// associates v with the prefix for LookupValue/RemoveValue to retrieve.
+func (t *Table) AddValue(prefix netip.Prefix, nextHop NextHop, v RouteValue) error {
+	if err := t.Insert(prefix, nextHop); err != nil {
+		return err
+	}
+
+	if t.values == nil {
+		t.values = make(map[netip.Prefix]RouteValue)
+	}
+	t.values[prefix.Masked()] = v
+	return nil
+}
+
+// LookupValue performs the same longest-prefix match Lookup does, and
+// additionally reports the RouteValue AddValue associated with the
+// matched prefix. ok is false if there's no match, or if the matched
+// prefix was installed via Insert rather than AddValue - v is then
+// RouteValue's zero value, nil, either way.
+//
+// LookupValue is built on LookupPrefix, so it inherits that method's O(n)
+// route-shadow scan (see LookupPrefix's doc comment in query.go) rather
+// than Lookup's cgo-backed speed; it's meant for attaching metadata to a
+// match, not for a per-packet hot path against a RIB-sized table.
+func (t *Table) LookupValue(addr netip.Addr) (v RouteValue, ok bool) {
+	if t.closed {
+		return nil, false
+	}
+
+	prefix, _, found := t.LookupPrefix(addr)
+	if !found {
+		return nil, false
+	}
+	v, ok = t.values[prefix]
+	return v, ok
+}
+
+// RemoveValue deletes prefix, like Delete, and forgets any RouteValue
+// AddValue associated with it. It's Delete alone: forgetRoute (see
+// serialize.go) already clears the value shadow on every deletion, not
+// just ones RemoveValue initiates, so there's nothing left to do here.
+func (t *Table) RemoveValue(prefix netip.Prefix) error {
+	return t.Delete(prefix)
+}
@@ -0,0 +1,127 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+type routePolicy struct {
+	ASN    uint32
+	Origin string
+}
+
+func TestAddValueAndLookupValue(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	policy := &routePolicy{ASN: 65000, Origin: "IGP"}
+	if err := table.AddValue(prefix, 1, policy); err != nil {
+		t.Fatalf("AddValue failed: %v", err)
+	}
+
+	nh, ok := table.Lookup(netip.MustParseAddr("10.0.0.1"))
+	if !ok || nh != 1 {
+		t.Errorf("Lookup(10.0.0.1) = %v, %v; want 1, true", nh, ok)
+	}
+
+	v, ok := table.LookupValue(netip.MustParseAddr("10.0.0.1"))
+	if !ok {
+		t.Fatalf("LookupValue(10.0.0.1) ok = false, want true")
+	}
+	got, isPolicy := v.(*routePolicy)
+	if !isPolicy || got != policy {
+		t.Errorf("LookupValue(10.0.0.1) = %v, want %v", v, policy)
+	}
+}
+
+func TestLookupValueMissingForPlainInsert(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if _, ok := table.LookupValue(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Errorf("LookupValue on a plain Insert route ok = true, want false")
+	}
+}
+
+func TestLookupValueNoMatch(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if _, ok := table.LookupValue(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Errorf("LookupValue with no routes installed ok = true, want false")
+	}
+}
+
+func TestRemoveValueForgetsValueAndRoute(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.AddValue(prefix, 1, "some metadata"); err != nil {
+		t.Fatalf("AddValue failed: %v", err)
+	}
+
+	if err := table.RemoveValue(prefix); err != nil {
+		t.Fatalf("RemoveValue failed: %v", err)
+	}
+
+	if _, ok := table.Lookup(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Errorf("Lookup after RemoveValue ok = true, want false")
+	}
+	if _, ok := table.LookupValue(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Errorf("LookupValue after RemoveValue ok = true, want false")
+	}
+}
+
+func TestPlainInsertClearsStaleValue(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.AddValue(prefix, 1, "policy-A"); err != nil {
+		t.Fatalf("AddValue failed: %v", err)
+	}
+	if err := table.Delete(prefix); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := table.Insert(prefix, 2); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if _, ok := table.LookupValue(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Errorf("LookupValue after Delete + plain Insert ok = true, want false (stale value leaked)")
+	}
+}
+
+func TestAddValueRejectsWrongFamily(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	err = table.AddValue(netip.MustParsePrefix("2001:db8::/32"), 1, "x")
+	if err == nil {
+		t.Errorf("AddValue with an IPv6 prefix on an IPv4 table = nil error, want one")
+	}
+}
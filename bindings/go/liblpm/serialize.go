@@ -0,0 +1,392 @@
+package liblpm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/netip"
+)
+
+// Binary snapshot format written by WriteTo and read back by ReadFrom:
+//
+//	offset  size  field
+//	0       4     magic, "LPMS"
+//	4       1     version, currently snapshotVersion
+//	5       1     address family: snapshotAFIPv4 or snapshotAFIPv6
+//	6       4     route count, big-endian uint32
+//	10      N*rec one fixed-width record per route:
+//	                  4 or 16 bytes  masked prefix address (per AF)
+//	                  1 byte         prefix length
+//	                  4 bytes        next hop, big-endian uint32
+//	10+N*rec 4    CRC32C (Castagnoli) of everything above, big-endian
+//
+// Only non-multipath routes (installed via Insert or AddBatch) are
+// covered; InsertMultipath groups aren't representable in this format and
+// are silently excluded.
+const (
+	snapshotMagic   = "LPMS"
+	snapshotVersion = 1
+
+	snapshotAFIPv4 = 4
+	snapshotAFIPv6 = 6
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Route is a single (prefix, next hop) pair, the unit WriteTo/ReadFrom
+// serialize and NewTableFromSnapshot/SafeTable.ReplaceAll consume.
+type Route struct {
+	Prefix  netip.Prefix
+	NextHop NextHop
+}
+
+// recordRoute updates the snapshot shadow that WriteTo/MarshalBinary read
+// from with a newly installed non-multipath route. Insert and AddBatch
+// call this; InsertMultipath groups aren't representable in the snapshot
+// format and are never added. It also clears any RouteValue a previous
+// AddValue attached to prefix: a plain (re)install through Insert isn't
+// an AddValue call, so route_value.go's invariant - a value survives
+// only as long as the AddValue call that set it - would otherwise leak a
+// stale value onto an unrelated route reinstalled at the same prefix.
+func (t *Table) recordRoute(prefix netip.Prefix, nextHop NextHop) {
+	if t.routes == nil {
+		t.routes = make(map[netip.Prefix]NextHop)
+	}
+	masked := prefix.Masked()
+	t.routes[masked] = nextHop
+	delete(t.values, masked)
+}
+
+// forgetRoute removes prefix from the snapshot shadow, and from the
+// RouteValue shadow route_value.go maintains alongside it. Called
+// whenever a route is deleted (including via RemoveBatch), or
+// overwritten by InsertMultipath.
+func (t *Table) forgetRoute(prefix netip.Prefix) {
+	masked := prefix.Masked()
+	delete(t.routes, masked)
+	delete(t.values, masked)
+}
+
+// MarshalBinary encodes the table's routes in the format WriteTo
+// documents. It satisfies encoding.BinaryMarshaler.
+func (t *Table) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := t.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the table's routes with the ones decoded from
+// data, produced by MarshalBinary. It satisfies encoding.BinaryUnmarshaler.
+func (t *Table) UnmarshalBinary(data []byte) error {
+	_, err := t.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// WriteTo streams the table's routes to w in the versioned binary
+// snapshot format documented above, so they can be persisted across
+// restarts or shipped to another process. Returns the number of bytes
+// written.
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	if t.closed {
+		return 0, ErrTableClosed
+	}
+
+	af := byte(snapshotAFIPv6)
+	addrLen := 16
+	if t.isIPv4 {
+		af = snapshotAFIPv4
+		addrLen = 4
+	}
+
+	cw := &crcCountingWriter{w: w, crc: crc32.New(crc32cTable)}
+
+	header := make([]byte, 4+1+1+4)
+	copy(header, snapshotMagic)
+	header[4] = snapshotVersion
+	header[5] = af
+	binary.BigEndian.PutUint32(header[6:], uint32(len(t.routes)))
+	if _, err := cw.Write(header); err != nil {
+		return cw.n, err
+	}
+
+	record := make([]byte, addrLen+1+4)
+	for prefix, nextHop := range t.routes {
+		addr := prefix.Addr()
+		if addrLen == 4 {
+			a4 := addr.As4()
+			copy(record, a4[:])
+		} else {
+			a16 := addr.As16()
+			copy(record, a16[:])
+		}
+		record[addrLen] = byte(prefix.Bits())
+		binary.BigEndian.PutUint32(record[addrLen+1:], uint32(nextHop))
+		if _, err := cw.Write(record); err != nil {
+			return cw.n, err
+		}
+	}
+
+	var trailer [4]byte
+	binary.BigEndian.PutUint32(trailer[:], cw.crc.Sum32())
+	n, err := w.Write(trailer[:])
+	return cw.n + int64(n), err
+}
+
+// ReadFrom replaces the table's routes with the ones decoded from r,
+// produced by WriteTo. The header's address family must match the
+// table's; the trailing CRC32C is verified before anything is installed.
+// The replacement routes are loaded into a freshly created trie and
+// only swapped in once every one of them has been applied successfully,
+// so a snapshot that's well-formed (right magic/version/AF, correct
+// checksum) but fails partway through loading - for example a record
+// whose prefix length is out of range for the family - leaves the
+// table exactly as it was rather than half torn down. As with
+// SafeTable.ReplaceAll, any multipath groups installed on the table
+// don't survive - they aren't part of the wire format. Returns the
+// number of bytes read.
+func (t *Table) ReadFrom(r io.Reader) (int64, error) {
+	if t.closed {
+		return 0, ErrTableClosed
+	}
+
+	routes, n, err := decodeSnapshot(r, t.isIPv4)
+	if err != nil {
+		return n, err
+	}
+
+	family := FamilyIPv6
+	if t.isIPv4 {
+		family = FamilyIPv4
+	}
+	newTrie, err := createTrie(family, t.algorithm)
+	if err != nil {
+		return n, err
+	}
+
+	newRoutes := make(map[netip.Prefix]NextHop, len(routes))
+	for _, route := range routes {
+		prefixBytes, prefixLen, err := prefixToBytes(route.Prefix)
+		if err != nil {
+			cDestroy(newTrie)
+			return n, err
+		}
+		if err := cAdd(newTrie, prefixBytes, prefixLen, uint32(route.NextHop)); err != nil {
+			cDestroy(newTrie)
+			return n, err
+		}
+		newRoutes[route.Prefix.Masked()] = route.NextHop
+	}
+
+	oldTrie := t.cTrie
+	t.cTrie = newTrie
+	t.routes = newRoutes
+	// The snapshot format doesn't carry RouteValue (see route_value.go),
+	// and the whole point of ReadFrom is replacing t's routes with an
+	// external, value-less set - so any values from before the reload
+	// are about prefixes this call is discarding wholesale, not ones it
+	// can reattach to anything meaningful.
+	t.values = nil
+	t.groups = nil
+	t.nextGroupID = 0
+	t.freeGroupIDs = nil
+	cDestroy(oldTrie)
+
+	return n, nil
+}
+
+// Clone returns an independent copy of t holding the same routes, for
+// publishing a mutated copy without disturbing readers of the original
+// (see SafeTable.Update). It's built on MarshalBinary/NewTableFromSnapshot
+// rather than a dedicated C entry point, so it's a deep copy rather than
+// the structural sharing a refcounted C trie could offer - same
+// limitation BatchTable.Snapshot documents for BackendCGO - and the same
+// InsertMultipath caveat as WriteTo/ReadFrom applies: multipath groups
+// aren't part of the route shadow Clone copies from and so don't
+// survive the clone. Like NewTableFromSnapshot, the clone is always
+// built with the family's default algorithm (DIR-24-8 for IPv4, wide16
+// for IPv6) regardless of which constructor - say,
+// NewTableIPv4Stride8 - produced t; picking a non-default algorithm and
+// then cloning it (directly, or via SafeTable.Update) silently reverts
+// to the default on the first clone.
+//
+// RouteValue associations (route_value.go) don't round-trip through the
+// snapshot format NewTableFromSnapshot reads either, so Clone copies
+// t.values across directly afterwards - otherwise every SafeTable.Update
+// call, which clones under the hood, would silently erase every
+// AddValue a caller had ever made.
+func (t *Table) Clone() (*Table, error) {
+	if t.closed {
+		return nil, ErrTableClosed
+	}
+
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	clone, err := NewTableFromSnapshot(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.values) > 0 {
+		clone.values = make(map[netip.Prefix]RouteValue, len(t.values))
+		for prefix, v := range t.values {
+			clone.values[prefix] = v
+		}
+	}
+	return clone, nil
+}
+
+// WriteSnapshot is WriteTo under the name callers reaching for explicit
+// persistence (rather than implementing io.WriterTo generically) tend to
+// look for. It writes the exact same wire format WriteTo documents above
+// - there's no second format here, just the two names.
+func (t *Table) WriteSnapshot(w io.Writer) (int64, error) {
+	return t.WriteTo(w)
+}
+
+// NewTableFromSnapshot creates a new table from a snapshot produced by
+// WriteTo/WriteSnapshot/MarshalBinary, inferring the address family from
+// its header and using the family's default algorithm. It is
+// LoadSnapshot with AlgorithmDefault.
+func NewTableFromSnapshot(r io.Reader) (*Table, error) {
+	return LoadSnapshot(r, AlgorithmDefault)
+}
+
+// LoadSnapshot is NewTableFromSnapshot with control over which algorithm
+// the restored trie uses - so a snapshot taken from, say, an 8-stride
+// IPv4 table can be materialized into a DIR-24-8 one, or vice versa. The
+// address family is still inferred from the snapshot header; alg must be
+// valid for that family (see NewTable) or AlgorithmDefault, which always
+// is.
+func LoadSnapshot(r io.Reader, alg Algorithm) (*Table, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(6)
+	if err != nil {
+		return nil, fmt.Errorf("liblpm: reading snapshot header: %w", err)
+	}
+
+	family := FamilyIPv6
+	if header[5] == snapshotAFIPv4 {
+		family = FamilyIPv4
+	}
+
+	table, err := NewTable(TableOptions{Family: family, Algorithm: alg})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := table.ReadFrom(br); err != nil {
+		table.Close()
+		return nil, err
+	}
+	return table, nil
+}
+
+// decodeSnapshot validates and parses a snapshot's header, records and
+// trailing checksum, returning the decoded routes without touching any
+// table. isIPv4 must match the snapshot's recorded address family.
+func decodeSnapshot(r io.Reader, isIPv4 bool) ([]Route, int64, error) {
+	cr := &countingReader{r: r}
+
+	header := make([]byte, 4+1+1+4)
+	if _, err := io.ReadFull(cr, header); err != nil {
+		return nil, cr.n, fmt.Errorf("liblpm: reading snapshot header: %w", err)
+	}
+	if string(header[0:4]) != snapshotMagic {
+		return nil, cr.n, errors.New("liblpm: not a liblpm snapshot (bad magic)")
+	}
+	if header[4] != snapshotVersion {
+		return nil, cr.n, fmt.Errorf("liblpm: unsupported snapshot version %d", header[4])
+	}
+
+	addrLen := 16
+	wantAF := byte(snapshotAFIPv6)
+	if isIPv4 {
+		addrLen = 4
+		wantAF = snapshotAFIPv4
+	}
+	if header[5] != wantAF {
+		return nil, cr.n, fmt.Errorf("liblpm: snapshot address family (%d) doesn't match table (%d)", header[5], wantAF)
+	}
+
+	count := binary.BigEndian.Uint32(header[6:])
+
+	crc := crc32.New(crc32cTable)
+	crc.Write(header)
+
+	// Capacity is capped rather than sized directly off count: count comes
+	// straight from the (not yet checksum-verified) header, and a
+	// corrupt/truncated snapshot claiming billions of records shouldn't
+	// make this allocate gigabytes before the subsequent reads ever get a
+	// chance to fail on EOF.
+	const maxPreallocRoutes = 4096
+	preallocRoutes := count
+	if preallocRoutes > maxPreallocRoutes {
+		preallocRoutes = maxPreallocRoutes
+	}
+	routes := make([]Route, 0, preallocRoutes)
+	record := make([]byte, addrLen+1+4)
+	for i := uint32(0); i < count; i++ {
+		if _, err := io.ReadFull(cr, record); err != nil {
+			return nil, cr.n, fmt.Errorf("liblpm: reading snapshot record %d: %w", i, err)
+		}
+		crc.Write(record)
+
+		var addr netip.Addr
+		if addrLen == 4 {
+			addr = netip.AddrFrom4([4]byte(record[:4]))
+		} else {
+			addr = netip.AddrFrom16([16]byte(record[:16]))
+		}
+		prefixLen := int(record[addrLen])
+		nextHop := NextHop(binary.BigEndian.Uint32(record[addrLen+1:]))
+		routes = append(routes, Route{Prefix: netip.PrefixFrom(addr, prefixLen), NextHop: nextHop})
+	}
+
+	var trailer [4]byte
+	if _, err := io.ReadFull(cr, trailer[:]); err != nil {
+		return nil, cr.n, fmt.Errorf("liblpm: reading snapshot checksum: %w", err)
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != crc.Sum32() {
+		return nil, cr.n, errors.New("liblpm: snapshot checksum mismatch")
+	}
+
+	return routes, cr.n, nil
+}
+
+// crcCountingWriter forwards writes to w while folding them into a
+// running CRC32C and tracking the total byte count written, so WriteTo
+// can compute its trailer and return value in one pass over the records.
+type crcCountingWriter struct {
+	w   io.Writer
+	crc hash.Hash32
+	n   int64
+}
+
+func (c *crcCountingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.crc.Write(p[:n])
+	return n, err
+}
+
+// countingReader wraps r to track the total bytes read, for ReadFrom's
+// return value.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,256 @@
+package liblpm
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestWriteToReadFromRoundTrip(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	routes := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 2},
+		{Prefix: netip.MustParsePrefix("0.0.0.0/0"), NextHop: 3},
+	}
+	for _, r := range routes {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := table.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	restored, err := NewTableFromSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("NewTableFromSnapshot failed: %v", err)
+	}
+	defer restored.Close()
+
+	for _, r := range routes {
+		nh, ok := restored.Lookup(r.Prefix.Addr())
+		if !ok || nh != r.NextHop {
+			t.Errorf("Lookup(%v) after restore = %v/%v, want %v/true", r.Prefix.Addr(), nh, ok, r.NextHop)
+		}
+	}
+}
+
+func TestMarshalBinaryUnmarshalBinaryRoundTrip(t *testing.T) {
+	table, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("2001:db8::/32")
+	if err := table.Insert(prefix, 42); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	data, err := table.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	other, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create second table: %v", err)
+	}
+	defer other.Close()
+
+	if err := other.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+
+	nh, ok := other.Lookup(prefix.Addr())
+	if !ok || nh != 42 {
+		t.Errorf("Lookup after UnmarshalBinary = %v/%v, want 42/true", nh, ok)
+	}
+}
+
+func TestReadFromRejectsAddressFamilyMismatch(t *testing.T) {
+	v4, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create IPv4 table: %v", err)
+	}
+	defer v4.Close()
+	if err := v4.Insert(netip.MustParsePrefix("10.0.0.0/8"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := v4.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	v6, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create IPv6 table: %v", err)
+	}
+	defer v6.Close()
+
+	if _, err := v6.ReadFrom(&buf); err == nil {
+		t.Error("Expected ReadFrom to reject a snapshot from a table of the other address family")
+	}
+}
+
+func TestReadFromRejectsCorruptChecksum(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/8"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	data, err := table.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	data[len(data)-1] ^= 0xFF
+
+	other, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create second table: %v", err)
+	}
+	defer other.Close()
+
+	if err := other.UnmarshalBinary(data); err == nil {
+		t.Error("Expected UnmarshalBinary to reject a corrupted checksum")
+	}
+}
+
+func TestReplaceAllSwapsAtomically(t *testing.T) {
+	st, err := NewSafeTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create safe table: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.Insert(netip.MustParsePrefix("192.0.2.0/24"), 7); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	newRoutes := []Route{
+		{Prefix: netip.MustParsePrefix("203.0.113.0/24"), NextHop: 9},
+	}
+	if err := st.ReplaceAll(newRoutes); err != nil {
+		t.Fatalf("ReplaceAll failed: %v", err)
+	}
+
+	if _, ok := st.Lookup(netip.MustParseAddr("192.0.2.1")); ok {
+		t.Error("Expected route replaced by ReplaceAll to be gone")
+	}
+	nh, ok := st.Lookup(netip.MustParseAddr("203.0.113.1"))
+	if !ok || nh != 9 {
+		t.Errorf("Lookup after ReplaceAll = %v/%v, want 9/true", nh, ok)
+	}
+}
+
+func TestReplaceAllRejectsClosedTable(t *testing.T) {
+	st, err := NewSafeTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create safe table: %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := st.ReplaceAll(nil); err != ErrTableClosed {
+		t.Errorf("ReplaceAll on a closed table = %v, want ErrTableClosed", err)
+	}
+}
+
+func TestSafeTableWriteTo(t *testing.T) {
+	st, err := NewSafeTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create safe table: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.Insert(netip.MustParsePrefix("198.51.100.0/24"), 5); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := st.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	restored, err := NewTableFromSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("NewTableFromSnapshot failed: %v", err)
+	}
+	defer restored.Close()
+
+	nh, ok := restored.Lookup(netip.MustParseAddr("198.51.100.1"))
+	if !ok || nh != 5 {
+		t.Errorf("Lookup after restore = %v/%v, want 5/true", nh, ok)
+	}
+}
+
+func TestInsertMultipathOverwriteForgetsSerializedRoute(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.Insert(prefix, 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := table.InsertMultipath(prefix, []NextHop{1, 2}, []uint16{1, 1}); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	if _, ok := table.routes[prefix]; ok {
+		t.Error("Expected InsertMultipath to remove the prior plain-route shadow entry it overwrote")
+	}
+}
+
+func TestReadFromClearsStaleRouteValues(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.AddValue(prefix, 1, "policy-A"); err != nil {
+		t.Fatalf("AddValue failed: %v", err)
+	}
+
+	// A snapshot reinstalling the same prefix, but via plain Insert -
+	// the snapshot format has no RouteValue of its own (see
+	// route_value.go).
+	other, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create other table: %v", err)
+	}
+	defer other.Close()
+	if err := other.Insert(prefix, 2); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := other.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if _, err := table.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+
+	if _, ok := table.LookupValue(netip.MustParseAddr("10.0.0.1")); ok {
+		t.Error("Expected ReadFrom to clear RouteValue entries from before the reload")
+	}
+}
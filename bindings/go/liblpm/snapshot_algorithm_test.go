@@ -0,0 +1,93 @@
+package liblpm
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestWriteSnapshotMatchesWriteTo(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("10.0.0.0/24"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var fromWriteTo, fromWriteSnapshot bytes.Buffer
+	if _, err := table.WriteTo(&fromWriteTo); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if _, err := table.WriteSnapshot(&fromWriteSnapshot); err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+	if !bytes.Equal(fromWriteTo.Bytes(), fromWriteSnapshot.Bytes()) {
+		t.Errorf("WriteSnapshot produced a different wire format than WriteTo")
+	}
+}
+
+func TestLoadSnapshotWithAlgorithm(t *testing.T) {
+	source, err := NewTable(TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmStride8})
+	if err != nil {
+		t.Fatalf("Failed to create source table: %v", err)
+	}
+	defer source.Close()
+
+	routes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+	}
+	for i, p := range routes {
+		if err := source.Insert(p, NextHop(i+1)); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", p, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := source.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+
+	restored, err := LoadSnapshot(&buf, AlgorithmStride8)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+	defer restored.Close()
+
+	// The whole point of LoadSnapshot's algorithm argument is that the
+	// restored trie uses it instead of silently falling back to the
+	// family's default (AlgorithmDir24_8 for IPv4) the way
+	// NewTableFromSnapshot does - ReadFrom rebuilds the trie via
+	// createTrie(family, t.algorithm), so this checks that field rather
+	// than trie internals the package doesn't expose.
+	if restored.algorithm != AlgorithmStride8 {
+		t.Errorf("restored.algorithm = %v, want AlgorithmStride8", restored.algorithm)
+	}
+
+	for i, p := range routes {
+		nh, ok := restored.Lookup(p.Addr())
+		if !ok || nh != NextHop(i+1) {
+			t.Errorf("Lookup(%v) = %v, %v; want %v, true", p.Addr(), nh, ok, i+1)
+		}
+	}
+}
+
+func TestLoadSnapshotRejectsMismatchedAlgorithm(t *testing.T) {
+	source, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create source table: %v", err)
+	}
+	defer source.Close()
+
+	var buf bytes.Buffer
+	if _, err := source.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+
+	if _, err := LoadSnapshot(&buf, AlgorithmWide16); err == nil {
+		t.Errorf("LoadSnapshot with AlgorithmWide16 for an IPv4 snapshot = nil error, want one")
+	}
+}
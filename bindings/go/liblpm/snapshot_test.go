@@ -0,0 +1,83 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestBatchTableSnapshotIsolation verifies that a Snapshot is unaffected
+// by Insert/Delete on the live table taken after it, and vice versa.
+func TestBatchTableSnapshotIsolation(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	wide := netip.MustParsePrefix("10.0.0.0/8")
+	if err := table.Insert(wide, 100); err != nil {
+		t.Fatalf("Failed to insert %s: %v", wide, err)
+	}
+
+	snap, err := table.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	defer snap.Close()
+
+	narrow := netip.MustParsePrefix("10.1.1.0/24")
+	if err := table.Insert(narrow, 300); err != nil {
+		t.Fatalf("Failed to insert %s: %v", narrow, err)
+	}
+	if _, _, err := table.Delete(wide); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	liveResults, err := table.LookupBatch([]netip.Addr{netip.MustParseAddr("10.1.1.1")})
+	if err != nil {
+		t.Fatalf("LookupBatch failed: %v", err)
+	}
+	if liveResults[0] != 300 {
+		t.Errorf("Expected live table to see the new /24 route, got %d", liveResults[0])
+	}
+
+	snapResults, err := snap.LookupBatch([]netip.Addr{netip.MustParseAddr("10.1.1.1")})
+	if err != nil {
+		t.Fatalf("Snapshot LookupBatch failed: %v", err)
+	}
+	if snapResults[0] != 100 {
+		t.Errorf("Expected snapshot to keep seeing the original /8 route (100), got %d", snapResults[0])
+	}
+
+	if err := snap.Insert(netip.MustParsePrefix("1.2.3.0/24"), 1); err == nil {
+		t.Error("Expected Insert on a snapshot to fail")
+	}
+}
+
+// TestBatchTableSnapshotRequiresIdentity verifies Snapshot is rejected for
+// a BatchTable whose value type isn't NextHop.
+func TestBatchTableSnapshotRequiresIdentity(t *testing.T) {
+	table, err := NewBatchTableIPv4Generic[routeMeta](BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create generic batch table: %v", err)
+	}
+	defer table.Close()
+
+	if _, err := table.Snapshot(); err == nil {
+		t.Error("Expected Snapshot to fail for a non-NextHop BatchTable")
+	}
+}
+
+// TestBatchTableSnapshotRequiresPureGo verifies Snapshot is rejected for
+// the cgo backend, which has no node-level COW support.
+func TestBatchTableSnapshotRequiresPureGo(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendCGO)
+	if err != nil {
+		t.Fatalf("Failed to create cgo batch table: %v", err)
+	}
+	defer table.Close()
+
+	if _, err := table.Snapshot(); err == nil {
+		t.Error("Expected Snapshot to fail for BackendCGO")
+	}
+}
@@ -0,0 +1,78 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestGetStatsCountsPrefixesByLength(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	routes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+	}
+	for _, p := range routes {
+		if err := table.Insert(p, 1); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", p, err)
+		}
+	}
+
+	stats, err := table.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+
+	if stats.NumPrefixes != uint64(len(routes)) {
+		t.Errorf("NumPrefixes = %d, want %d", stats.NumPrefixes, len(routes))
+	}
+	if len(stats.PrefixesByLength) != 33 {
+		t.Fatalf("len(PrefixesByLength) = %d, want 33 for an IPv4 table", len(stats.PrefixesByLength))
+	}
+	if stats.PrefixesByLength[24] != 2 {
+		t.Errorf("PrefixesByLength[24] = %d, want 2", stats.PrefixesByLength[24])
+	}
+	if stats.PrefixesByLength[32] != 1 {
+		t.Errorf("PrefixesByLength[32] = %d, want 1", stats.PrefixesByLength[32])
+	}
+}
+
+func TestGetStatsIPv6Histogram(t *testing.T) {
+	table, err := NewTableIPv6()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Insert(netip.MustParsePrefix("2001:db8::/32"), 1); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	stats, err := table.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if len(stats.PrefixesByLength) != 129 {
+		t.Fatalf("len(PrefixesByLength) = %d, want 129 for an IPv6 table", len(stats.PrefixesByLength))
+	}
+	if stats.PrefixesByLength[32] != 1 {
+		t.Errorf("PrefixesByLength[32] = %d, want 1", stats.PrefixesByLength[32])
+	}
+}
+
+func TestGetStatsOnClosedTable(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	table.Close()
+
+	if _, err := table.GetStats(); err != ErrTableClosed {
+		t.Errorf("GetStats on a closed table = %v, want ErrTableClosed", err)
+	}
+}
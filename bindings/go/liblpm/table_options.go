@@ -0,0 +1,133 @@
+package liblpm
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Family selects the IP address family NewTable builds a Table for.
+type Family int
+
+const (
+	FamilyIPv4 Family = iota
+	FamilyIPv6
+)
+
+// Algorithm selects the trie layout NewTable builds. AlgorithmDefault (the
+// zero value) picks each family's historical default - the same one
+// NewTableIPv4/NewTableIPv6 have always built - rather than one of the
+// explicit choices below.
+type Algorithm int
+
+const (
+	AlgorithmDefault Algorithm = iota
+	AlgorithmDir24_8
+	AlgorithmStride8
+	AlgorithmWide16
+	AlgorithmART
+)
+
+// errAlgorithmARTUnsupported is NewTable's error for AlgorithmART. Table
+// calls the C trie directly everywhere (finalize, Insert, Delete, Lookup,
+// InsertMultipath, Clone all read or write t.cTrie with no abstraction in
+// between), unlike BatchTable, which already picks between a cgo and a
+// pure-Go backend through its batchBackend interface. Giving Table the
+// same choice would mean threading that interface through every one of
+// those call sites for one algorithm option; the pure-Go ART
+// implementation this would need already exists (art.go's
+// artBatchBackend, built for BatchTable) but isn't something Table can
+// take on without that wider rewrite. A caller that wants it today can
+// have it: NewBatchTableIPv4WithBackend/NewBatchTableIPv6WithBackend with
+// BackendPureGo is the same Hariguchi ART algorithm, just on BatchTable
+// instead of Table.
+var errAlgorithmARTUnsupported = errors.New("liblpm: AlgorithmART is not supported on Table; use NewBatchTableIPv4WithBackend/NewBatchTableIPv6WithBackend with BackendPureGo instead")
+
+// TableOptions configures NewTable.
+type TableOptions struct {
+	Family    Family
+	Algorithm Algorithm
+
+	// MaxPrefixes hints how many prefixes the table will eventually hold,
+	// for pre-sizing internal arrays. None of cgo.go's C trie
+	// constructors take a presizing argument yet, so NewTable currently
+	// accepts this and does nothing with it. It's here so callers can
+	// start passing a real estimate now and get the benefit automatically
+	// whenever a presizing C entry point is added, rather than needing an
+	// API change on their side too.
+	MaxPrefixes int
+
+	// Hasher overrides the keyed SipHash-2-4 that InsertMultipath's flow
+	// lookups (LookupFlow, LookupFlowBatch) use to pick a member of an
+	// ECMP group, letting a caller pin a deterministic or
+	// application-specific hash instead of the random per-table key
+	// ensureMultipath otherwise generates. Most callers should leave this
+	// nil. It has no effect on a table that never calls InsertMultipath.
+	//
+	// There is no NextHopCodec hook here: a Table's next hop is a fixed
+	// NextHop (uint32), the type the C trie itself stores, not something
+	// a codec could reinterpret without a change on the C side. A caller
+	// that wants a richer value per prefix already has BatchTable[V],
+	// whose generic slab exists for exactly that.
+	Hasher func(data []byte) uint64
+}
+
+// NewTable creates a new routing table per opts. NewTableIPv4,
+// NewTableIPv4Dir24, NewTableIPv4Stride8, NewTableIPv6, NewTableIPv6Wide16
+// and NewTableIPv6Stride8 are thin wrappers over this, kept for callers
+// who already know their family and algorithm at compile time.
+func NewTable(opts TableOptions) (*Table, error) {
+	triePtr, err := createTrie(opts.Family, opts.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{
+		cTrie:     triePtr,
+		isIPv4:    opts.Family == FamilyIPv4,
+		algorithm: opts.Algorithm,
+		hasher:    opts.Hasher,
+	}
+	runtime.SetFinalizer(t, (*Table).finalize)
+	return t, nil
+}
+
+// createTrie builds a C trie for family/algorithm, the constructor
+// dispatch NewTable uses and ReadFrom (serialize.go) reuses to rebuild a
+// trie with the same algorithm a table was originally created with.
+func createTrie(family Family, algorithm Algorithm) (uintptr, error) {
+	switch family {
+	case FamilyIPv4:
+		switch algorithm {
+		case AlgorithmDefault:
+			return cCreateIPv4()
+		case AlgorithmDir24_8:
+			return cCreateIPv4Dir24()
+		case AlgorithmStride8:
+			return cCreateIPv4Stride8()
+		case AlgorithmWide16:
+			return 0, fmt.Errorf("liblpm: AlgorithmWide16 is IPv6-only")
+		case AlgorithmART:
+			return 0, errAlgorithmARTUnsupported
+		default:
+			return 0, fmt.Errorf("liblpm: unknown Algorithm %d", algorithm)
+		}
+	case FamilyIPv6:
+		switch algorithm {
+		case AlgorithmDefault:
+			return cCreateIPv6()
+		case AlgorithmWide16:
+			return cCreateIPv6Wide16()
+		case AlgorithmStride8:
+			return cCreateIPv6Stride8()
+		case AlgorithmDir24_8:
+			return 0, fmt.Errorf("liblpm: AlgorithmDir24_8 is IPv4-only")
+		case AlgorithmART:
+			return 0, errAlgorithmARTUnsupported
+		default:
+			return 0, fmt.Errorf("liblpm: unknown Algorithm %d", algorithm)
+		}
+	default:
+		return 0, fmt.Errorf("liblpm: unknown Family %d", family)
+	}
+}
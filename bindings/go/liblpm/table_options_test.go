@@ -0,0 +1,93 @@
+package liblpm
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestNewTableMatchesExplicitConstructors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts TableOptions
+	}{
+		{"IPv4Default", TableOptions{Family: FamilyIPv4}},
+		{"IPv4Dir24_8", TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmDir24_8}},
+		{"IPv4Stride8", TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmStride8}},
+		{"IPv6Default", TableOptions{Family: FamilyIPv6}},
+		{"IPv6Wide16", TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmWide16}},
+		{"IPv6Stride8", TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmStride8}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			table, err := NewTable(c.opts)
+			if err != nil {
+				t.Fatalf("NewTable(%+v) failed: %v", c.opts, err)
+			}
+			defer table.Close()
+
+			if table.isIPv4 != (c.opts.Family == FamilyIPv4) {
+				t.Errorf("isIPv4 = %v, want %v", table.isIPv4, c.opts.Family == FamilyIPv4)
+			}
+
+			prefix := netip.MustParsePrefix("10.0.0.0/24")
+			if !table.isIPv4 {
+				prefix = netip.MustParsePrefix("2001:db8::/32")
+			}
+			if err := table.Insert(prefix, 42); err != nil {
+				t.Fatalf("Insert failed: %v", err)
+			}
+			if nh, ok := table.LookupExact(prefix); !ok || nh != 42 {
+				t.Errorf("LookupExact = %v/%v, want 42/true", nh, ok)
+			}
+		})
+	}
+}
+
+func TestNewTableRejectsMismatchedAlgorithm(t *testing.T) {
+	if _, err := NewTable(TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmWide16}); err == nil {
+		t.Error("Expected an error for AlgorithmWide16 on an IPv4 table")
+	}
+	if _, err := NewTable(TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmDir24_8}); err == nil {
+		t.Error("Expected an error for AlgorithmDir24_8 on an IPv6 table")
+	}
+}
+
+func TestNewTableRejectsAlgorithmART(t *testing.T) {
+	if _, err := NewTable(TableOptions{Family: FamilyIPv4, Algorithm: AlgorithmART}); err == nil {
+		t.Error("Expected AlgorithmART to be rejected on Table")
+	}
+	if _, err := NewTable(TableOptions{Family: FamilyIPv6, Algorithm: AlgorithmART}); err == nil {
+		t.Error("Expected AlgorithmART to be rejected on Table")
+	}
+}
+
+func TestNewTableHasherOverridesFlowHash(t *testing.T) {
+	var calls int
+	table, err := NewTable(TableOptions{
+		Family: FamilyIPv4,
+		Hasher: func(data []byte) uint64 {
+			calls++
+			return 0
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTable failed: %v", err)
+	}
+	defer table.Close()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/24")
+	if err := table.InsertMultipath(prefix, []NextHop{1, 2}, []uint16{1, 1}); err != nil {
+		t.Fatalf("InsertMultipath failed: %v", err)
+	}
+
+	if _, ok := table.LookupFlow(
+		netip.MustParseAddr("1.2.3.4"), netip.MustParseAddr("10.0.0.1"), 6, 1234, 80,
+	); !ok {
+		t.Fatal("LookupFlow found no match")
+	}
+
+	if calls == 0 {
+		t.Error("Expected the custom Hasher to be called by LookupFlow")
+	}
+}
@@ -0,0 +1,110 @@
+package liblpm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// DumpText writes one "prefix nexthop" line per installed route to w, in
+// the same order Walk reports (ascending address, then ascending prefix
+// length). This is a much simpler, human-readable sibling of
+// WriteTo/MarshalBinary's versioned binary format - closer to what
+// `birdc show route` or `vtysh -c 'show ip route'` print than to a wire
+// format meant to round-trip exactly, and like Walk it only covers routes
+// installed via Insert; InsertMultipath groups aren't visited.
+func (t *Table) DumpText(w io.Writer) error {
+	if t.closed {
+		return ErrTableClosed
+	}
+
+	bw := bufio.NewWriter(w)
+	var walkErr error
+	t.Walk(func(prefix netip.Prefix, nextHop NextHop) bool {
+		if _, walkErr = fmt.Fprintf(bw, "%s %d\n", prefix, uint32(nextHop)); walkErr != nil {
+			return false
+		}
+		return true
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	return bw.Flush()
+}
+
+// LoadText creates a new table from r's "prefix nexthop" lines - the
+// DumpText format, and (loosely) what BIRD/FRR's show-route commands
+// print per route, a simple enough shape that callers piping that output
+// through a small awk/sed script could feed it straight to LoadText.
+// Blank lines and lines starting with "#" are skipped. The address
+// family is inferred from the first prefix seen; a later prefix of the
+// other family is a parse error rather than silently dropped, since a
+// comment was never given a fence format the way WriteTo's header has.
+//
+// This line-parsing duplicates fibio.LoadText's; they aren't shared
+// because they target different types (*Table here, *BatchTable there)
+// and the format itself is a handful of lines of "split on whitespace,
+// parse two fields" - not worth a shared helper for.
+func LoadText(r io.Reader) (*Table, error) {
+	scanner := bufio.NewScanner(r)
+	var table *Table
+	lineNum := 0
+
+	// fail closes table, if one has been created, before returning err -
+	// see LoadMRT's identical helper in mrt.go for why every error path
+	// below goes through it.
+	fail := func(err error) (*Table, error) {
+		if table != nil {
+			table.Close()
+		}
+		return nil, err
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fail(fmt.Errorf("liblpm: line %d: expected \"prefix nexthop\", got %q", lineNum, line))
+		}
+
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			return fail(fmt.Errorf("liblpm: line %d: %w", lineNum, err))
+		}
+		nextHop, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return fail(fmt.Errorf("liblpm: line %d: invalid next hop: %w", lineNum, err))
+		}
+
+		if table == nil {
+			if prefix.Addr().Is4() {
+				table, err = NewTableIPv4()
+			} else {
+				table, err = NewTableIPv6()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := table.Insert(prefix, NextHop(nextHop)); err != nil {
+			return fail(fmt.Errorf("liblpm: line %d: %w", lineNum, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fail(err)
+	}
+
+	if table == nil {
+		return nil, fmt.Errorf("liblpm: no routes found in input")
+	}
+	return table, nil
+}
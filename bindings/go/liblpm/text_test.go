@@ -0,0 +1,75 @@
+package liblpm
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestDumpTextAndLoadTextRoundTrip(t *testing.T) {
+	table, err := NewTableIPv4()
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	defer table.Close()
+
+	routes := []Route{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/24"), NextHop: 1},
+		{Prefix: netip.MustParsePrefix("10.0.1.0/24"), NextHop: 2},
+	}
+	for _, r := range routes {
+		if err := table.Insert(r.Prefix, r.NextHop); err != nil {
+			t.Fatalf("Insert(%v) failed: %v", r.Prefix, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := table.DumpText(&buf); err != nil {
+		t.Fatalf("DumpText failed: %v", err)
+	}
+
+	loaded, err := LoadText(&buf)
+	if err != nil {
+		t.Fatalf("LoadText failed: %v", err)
+	}
+	defer loaded.Close()
+
+	for _, r := range routes {
+		if nh, ok := loaded.LookupExact(r.Prefix); !ok || nh != r.NextHop {
+			t.Errorf("LookupExact(%v) = %v/%v, want %v/true", r.Prefix, nh, ok, r.NextHop)
+		}
+	}
+}
+
+func TestLoadTextSkipsBlankAndCommentLines(t *testing.T) {
+	input := "# comment\n\n10.0.0.0/24 1\n\n# another comment\n10.0.1.0/24 2\n"
+
+	table, err := LoadText(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadText failed: %v", err)
+	}
+	defer table.Close()
+
+	if nh, ok := table.LookupExact(netip.MustParsePrefix("10.0.1.0/24")); !ok || nh != 2 {
+		t.Errorf("LookupExact(10.0.1.0/24) = %v/%v, want 2/true", nh, ok)
+	}
+}
+
+func TestLoadTextRejectsMalformedLine(t *testing.T) {
+	if _, err := LoadText(strings.NewReader("10.0.0.0/24 1 extra\n")); err == nil {
+		t.Error("Expected an error for a line with too many fields")
+	}
+	if _, err := LoadText(strings.NewReader("not-a-prefix 1\n")); err == nil {
+		t.Error("Expected an error for an invalid prefix")
+	}
+	if _, err := LoadText(strings.NewReader("10.0.0.0/24 not-a-number\n")); err == nil {
+		t.Error("Expected an error for an invalid next hop")
+	}
+}
+
+func TestLoadTextEmptyInput(t *testing.T) {
+	if _, err := LoadText(strings.NewReader("")); err == nil {
+		t.Error("Expected an error for input with no routes")
+	}
+}
@@ -21,6 +21,39 @@ type Table struct {
 	cTrie  uintptr // Pointer to C lpm_trie_t (stored as uintptr to avoid cgo in types.go)
 	closed bool
 	isIPv4 bool
+
+	// algorithm is the Algorithm NewTable built this trie with, remembered
+	// so ReadFrom (serialize.go) can rebuild an equivalent trie on reload
+	// instead of silently reverting to the family's default.
+	algorithm Algorithm
+
+	// Multipath groups installed via InsertMultipath, keyed by the group
+	// ID stored (with multipathGroupFlag set) as the trie's next hop.
+	// Left nil until InsertMultipath is first called. See multipath.go.
+	groups       map[uint32]*multipathGroup
+	nextGroupID  uint32
+	freeGroupIDs []uint32
+	hashKey0     uint64
+	hashKey1     uint64
+
+	// hasher overrides the keyed SipHash-2-4 pickNextHop otherwise uses,
+	// when NewTable was called with TableOptions.Hasher set. Nil for
+	// every table built through the constructors below, which is the
+	// overwhelmingly common case.
+	hasher func(data []byte) uint64
+
+	// routes shadows every prefix installed via Insert, keyed by its
+	// masked form, so WriteTo/MarshalBinary can serialize the table's
+	// contents without a C-side trie walk (cgo.go exposes none yet).
+	// Left nil until the first Insert. InsertMultipath groups are not
+	// tracked here - snapshots don't yet cover them. See serialize.go.
+	routes map[netip.Prefix]NextHop
+
+	// values shadows the RouteValue AddValue associates with a prefix,
+	// on top of (not instead of) its NextHop in the C trie - a prefix
+	// installed via plain Insert has no entry here. Left nil until the
+	// first AddValue. See route_value.go.
+	values map[netip.Prefix]RouteValue
 }
 
 // NextHop represents a routing next hop identifier.
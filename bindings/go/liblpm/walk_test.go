@@ -0,0 +1,111 @@
+package liblpm
+
+import (
+	"net/netip"
+	"sort"
+	"testing"
+)
+
+// TestBatchTableWalk verifies Walk visits every explicitly installed
+// prefix exactly once, with its current value.
+func TestBatchTableWalk(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	prefixes := []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.0.0/24"}
+	for i, p := range prefixes {
+		if err := table.Insert(netip.MustParsePrefix(p), NextHop(i+1)); err != nil {
+			t.Fatalf("Failed to insert %s: %v", p, err)
+		}
+	}
+
+	var seen []string
+	err = table.Walk(func(prefix netip.Prefix, nextHop NextHop) bool {
+		seen = append(seen, prefix.String())
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	sort.Strings(seen)
+	want := []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.0.0/24"}
+	sort.Strings(want)
+	if len(seen) != len(want) {
+		t.Fatalf("Walk visited %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("Walk visited %v, want %v", seen, want)
+			break
+		}
+	}
+
+	// Early stop.
+	count := 0
+	table.Walk(func(prefix netip.Prefix, nextHop NextHop) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Expected Walk to stop after the first entry, visited %d", count)
+	}
+}
+
+// TestBatchTableStats checks the prefix/node counters and per-length
+// histogram reported by Stats.
+func TestBatchTableStats(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendPureGo)
+	if err != nil {
+		t.Fatalf("Failed to create pure-Go batch table: %v", err)
+	}
+	defer table.Close()
+
+	for _, p := range []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.0.0/16"} {
+		if err := table.Insert(netip.MustParsePrefix(p), 1); err != nil {
+			t.Fatalf("Failed to insert %s: %v", p, err)
+		}
+	}
+
+	st, err := table.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if st.NumPrefixes != 3 {
+		t.Errorf("Expected 3 prefixes, got %d", st.NumPrefixes)
+	}
+	if st.NumNodes == 0 {
+		t.Error("Expected at least one node")
+	}
+	if len(st.PrefixesByLength) != 33 {
+		t.Errorf("Expected PrefixesByLength sized for /0../32, got %d entries", len(st.PrefixesByLength))
+	}
+	if st.PrefixesByLength[8] != 1 || st.PrefixesByLength[16] != 2 {
+		t.Errorf("Unexpected PrefixesByLength: %v", st.PrefixesByLength)
+	}
+
+	summary := table.DebugSummary()
+	if summary == "" {
+		t.Error("Expected a non-empty DebugSummary")
+	}
+}
+
+// TestBatchTableWalkStatsRequirePureGo verifies Walk/Stats are rejected
+// against the cgo backend, which has no node enumeration.
+func TestBatchTableWalkStatsRequirePureGo(t *testing.T) {
+	table, err := NewBatchTableIPv4WithBackend(BackendCGO)
+	if err != nil {
+		t.Fatalf("Failed to create cgo batch table: %v", err)
+	}
+	defer table.Close()
+
+	if err := table.Walk(func(netip.Prefix, NextHop) bool { return true }); err == nil {
+		t.Error("Expected Walk to fail for BackendCGO")
+	}
+	if _, err := table.Stats(); err == nil {
+		t.Error("Expected Stats to fail for BackendCGO")
+	}
+}